@@ -0,0 +1,409 @@
+package inventory
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ArchiveVersion is the version of the archive format written by
+// WriteArchive. Archives of newer versions are rejected by ImportArchive.
+const ArchiveVersion = 1
+
+// Names of the files within an archive
+const (
+	archiveDocument    = "inventory.json"
+	archiveAttachments = "attachments/"
+)
+
+// ErrNotEmpty is returned by ImportArchive, if the database already contains
+// an inventory
+var ErrNotEmpty = errors.New("inventory: database is not empty")
+
+// A PartTag assigns a tag to a part
+type PartTag struct {
+	PartId int64 `db:"part_id"`
+	TagId  int64 `db:"tag_id"`
+}
+
+// An Archive is a database independent snapshot of an inventory. The ids are
+// those of the exported database, they are remapped by ImportArchive. Saved
+// views are not archived, as their queries refer to ids.
+type Archive struct {
+	Version          int
+	CreatedAt        time.Time
+	Users            []User
+	Categories       []Category
+	Places           []Place
+	Distributors     []Distributor
+	Parts            []Part
+	PartAmounts      []PartAmount
+	DistributorParts []DistributorPart
+	Attachments      []Attachment
+	Tags             []Tag
+	PartTags         []PartTag
+	Packagings       []Packaging
+}
+
+// LoadArchive reads the whole inventory, db should be a transaction to get a
+// consistent snapshot
+func LoadArchive(db sqlx.Queryer) (*Archive, error) {
+	a := &Archive{
+		Version:   ArchiveVersion,
+		CreatedAt: time.Now(),
+	}
+
+	tables := []struct {
+		dest  interface{}
+		query string
+	}{
+		{&a.Users, `SELECT * FROM 'user' ORDER BY "id" ASC`},
+		{&a.Categories, `SELECT * FROM 'category' ORDER BY "id" ASC`},
+		{&a.Places, `SELECT * FROM 'place' ORDER BY "id" ASC`},
+		{&a.Distributors, `SELECT * FROM 'distributor' ORDER BY "id" ASC`},
+		{&a.Parts, `SELECT * FROM 'part' ORDER BY "id" ASC`},
+		{&a.PartAmounts, `SELECT * FROM 'part_amount' ORDER BY "timestamp" ASC, "id" ASC`},
+		{&a.DistributorParts, `SELECT * FROM 'distributor_part' ORDER BY "id" ASC`},
+		{&a.Attachments, `SELECT * FROM 'attachment' ORDER BY "id" ASC`},
+		{&a.Tags, `SELECT * FROM 'tag' ORDER BY "id" ASC`},
+		{&a.PartTags, `SELECT * FROM 'part_tag' ORDER BY "part_id" ASC, "tag_id" ASC`},
+		{&a.Packagings, `SELECT * FROM 'packaging' ORDER BY "id" ASC`},
+	}
+	for _, table := range tables {
+		if err := sqlx.Select(db, table.dest, table.query); err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// WriteArchive writes a zip archive with the inventory as JSON document and
+// the blobs of all attachments. Attachments whose blob is missing in the
+// store are archived without blob.
+func WriteArchive(w io.Writer, db *sqlx.DB, store AttachmentStore) error {
+	tx := db.MustBegin()
+	defer tx.Rollback()
+
+	archive, err := LoadArchive(tx)
+	if err != nil {
+		return err
+	}
+
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create(archiveDocument)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	if err := encoder.Encode(archive); err != nil {
+		return err
+	}
+
+	for _, attachment := range archive.Attachments {
+		err := writeArchiveBlob(zw, store, attachment.Key)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+func writeArchiveBlob(zw *zip.Writer, store AttachmentStore, key []byte) error {
+	blob, err := store.Open(key)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	f, err := zw.Create(archiveAttachments + hex.EncodeToString(key))
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, blob)
+	return err
+}
+
+// archiveImporter keeps the mapping of archived ids to new ids
+type archiveImporter struct {
+	tx           *sqlx.Tx
+	store        AttachmentStore
+	blobs        map[string]*zip.File
+	users        map[int64]int64
+	categories   map[int64]int64
+	places       map[int64]int64
+	distributors map[int64]int64
+	parts        map[int64]int64
+	attachments  map[int64]int64
+	tags         map[int64]int64
+	// created contains the keys of the stored blobs, they are deleted if the
+	// import fails
+	created [][]byte
+}
+
+// ImportArchive imports an archive written by WriteArchive into an empty
+// database. All ids are remapped, distributors are matched by name, since
+// they are usually created by the schema.
+func ImportArchive(db *sqlx.DB, store AttachmentStore, r io.ReaderAt, size int64) (err error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+
+	im := &archiveImporter{
+		store:        store,
+		blobs:        make(map[string]*zip.File),
+		users:        make(map[int64]int64),
+		categories:   make(map[int64]int64),
+		places:       make(map[int64]int64),
+		distributors: make(map[int64]int64),
+		parts:        make(map[int64]int64),
+		attachments:  make(map[int64]int64),
+		tags:         make(map[int64]int64),
+	}
+
+	var document *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == archiveDocument:
+			document = f
+		case strings.HasPrefix(f.Name, archiveAttachments):
+			im.blobs[f.Name[len(archiveAttachments):]] = f
+		}
+	}
+	if document == nil {
+		return fmt.Errorf("inventory: archive contains no %s", archiveDocument)
+	}
+
+	archive := new(Archive)
+	rc, err := document.Open()
+	if err != nil {
+		return err
+	}
+	err = json.NewDecoder(rc).Decode(archive)
+	rc.Close()
+	if err != nil {
+		return err
+	}
+	if archive.Version > ArchiveVersion {
+		return fmt.Errorf("inventory: archive version %d is not supported", archive.Version)
+	}
+
+	im.tx = db.MustBegin()
+	defer func() {
+		if err != nil {
+			im.tx.Rollback()
+			for _, key := range im.created {
+				store.Delete(key)
+			}
+		}
+	}()
+
+	var count int64
+	err = im.tx.Get(&count, `SELECT (SELECT COUNT(*) FROM 'user') +
+	(SELECT COUNT(*) FROM 'category') + (SELECT COUNT(*) FROM 'place') +
+	(SELECT COUNT(*) FROM 'part')`)
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return ErrNotEmpty
+	}
+
+	err = im.importArchive(archive)
+	if err != nil {
+		return err
+	}
+	return im.tx.Commit()
+}
+
+// remap returns the new id of a nullable reference
+func remap(ids map[int64]int64, id sql.NullInt64) sql.NullInt64 {
+	if !id.Valid {
+		return id
+	}
+	newId, ok := ids[id.Int64]
+	return sql.NullInt64{Int64: newId, Valid: ok}
+}
+
+func (im *archiveImporter) importArchive(a *Archive) error {
+	for _, user := range a.Users {
+		id := user.Id
+		user.Id = 0
+		if err := user.Save(im.tx); err != nil {
+			return err
+		}
+		im.users[id] = user.Id
+	}
+
+	// Parents are set after all categories exist
+	for _, category := range a.Categories {
+		id := category.Id
+		category.Id, category.ParentId = 0, sql.NullInt64{}
+		if err := category.Save(im.tx); err != nil {
+			return err
+		}
+		im.categories[id] = category.Id
+	}
+	for _, category := range a.Categories {
+		if !category.ParentId.Valid {
+			continue
+		}
+		category.Id = im.categories[category.Id]
+		category.ParentId = remap(im.categories, category.ParentId)
+		if err := category.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	for _, place := range a.Places {
+		id := place.Id
+		place.Id = 0
+		if err := place.Save(im.tx); err != nil {
+			return err
+		}
+		im.places[id] = place.Id
+	}
+
+	for _, distributor := range a.Distributors {
+		id := distributor.Id
+		err := im.tx.Get(&distributor.Id, `SELECT "id" FROM 'distributor'
+		WHERE "name" = ?`, distributor.Name)
+		if err == sql.ErrNoRows {
+			distributor.Id = 0
+			err = distributor.Save(im.tx)
+		}
+		if err != nil {
+			return err
+		}
+		im.distributors[id] = distributor.Id
+	}
+
+	// Images are set after the attachments are imported
+	images := make(map[int64]int64)
+	for _, part := range a.Parts {
+		id := part.Id
+		if part.ImageId.Valid {
+			images[id] = part.ImageId.Int64
+		}
+		categoryId, ok := im.categories[part.CategoryId]
+		if !ok {
+			return fmt.Errorf("inventory: part %d has unknown category %d", id, part.CategoryId)
+		}
+		part.Id, part.CategoryId, part.ImageId = 0, categoryId, sql.NullInt64{}
+		part.PlaceId = remap(im.places, part.PlaceId)
+		part.OwnerId = remap(im.users, part.OwnerId)
+		if err := part.Save(im.tx); err != nil {
+			return err
+		}
+		im.parts[id] = part.Id
+	}
+
+	for _, amount := range a.PartAmounts {
+		amount.Id, amount.PartId = 0, im.parts[amount.PartId]
+		if err := amount.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	for _, distributorPart := range a.DistributorParts {
+		distributorPart.Id = 0
+		distributorPart.PartId = im.parts[distributorPart.PartId]
+		distributorPart.DistributorId = im.distributors[distributorPart.DistributorId]
+		if err := distributorPart.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	for _, attachment := range a.Attachments {
+		if err := im.importAttachment(attachment); err != nil {
+			return err
+		}
+	}
+	for partId, imageId := range images {
+		image, ok := im.attachments[imageId]
+		if !ok {
+			continue
+		}
+		_, err := im.tx.Exec(`UPDATE 'part' SET "image_id" = ? WHERE "id" = ?`,
+			image, im.parts[partId])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range a.Tags {
+		id := tag.Id
+		tag.Id = 0
+		if err := tag.Save(im.tx); err != nil {
+			return err
+		}
+		im.tags[id] = tag.Id
+	}
+	for _, partTag := range a.PartTags {
+		part := &Part{Id: im.parts[partTag.PartId]}
+		err := part.AddTag(im.tx, &Tag{Id: im.tags[partTag.TagId]})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, packaging := range a.Packagings {
+		packaging.Id, packaging.PartId = 0, im.parts[packaging.PartId]
+		if err := packaging.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importAttachment stores the blob of an attachment under a new key.
+// Attachments without blob are skipped.
+func (im *archiveImporter) importAttachment(attachment Attachment) error {
+	blob, ok := im.blobs[hex.EncodeToString(attachment.Key)]
+	if !ok {
+		return nil
+	}
+
+	src, err := blob.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, key, err := im.store.Create()
+	if err != nil {
+		return err
+	}
+	im.created = append(im.created, key)
+	_, err = io.Copy(dst, src)
+	if err == nil {
+		err = dst.Close()
+	} else {
+		dst.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	id := attachment.Id
+	attachment.Id, attachment.Key = 0, key
+	attachment.PartId = im.parts[attachment.PartId]
+	if err := attachment.Save(im.tx); err != nil {
+		return err
+	}
+	im.attachments[id] = attachment.Id
+	return nil
+}
@@ -0,0 +1,230 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type bulkChange struct {
+	Part   PartView
+	Change string
+}
+
+// bulkAction applies a change to a single part and describes what has been
+// changed. Returning an empty string means the part was left untouched.
+type bulkAction func(tx *sqlx.Tx, part *PartView) (string, error)
+
+// findOrCreateTag returns the tag with the given name and creates it if it does
+// not exist yet
+func findOrCreateTag(tx *sqlx.Tx, name string) (*Tag, error) {
+	tag := new(Tag)
+	err := tx.Get(tag, `SELECT * FROM 'tag' WHERE "name" = ?`, name)
+	switch err {
+	case sql.ErrNoRows:
+		tag.Name = name
+		err = tag.Save(tx)
+	}
+	return tag, err
+}
+
+func bulkMoveAction(tx *sqlx.Tx, form map[string][]string) (bulkAction, error) {
+	placeId, _ := strconv.ParseInt(first(form["place"]), 10, 64)
+
+	place := new(Place)
+	if placeId != 0 {
+		err := tx.Get(place, `SELECT * FROM 'place' WHERE "id" = ?`, placeId)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		place.Name = "(none)"
+	}
+
+	return func(tx *sqlx.Tx, part *PartView) (string, error) {
+		if part.PlaceId.Int64 == placeId && part.PlaceId.Valid == (placeId != 0) {
+			return "", nil
+		}
+		from := part.PlaceName.String
+		if !part.PlaceName.Valid {
+			from = "(none)"
+		}
+		part.PlaceId = sql.NullInt64{Int64: placeId, Valid: placeId != 0}
+		return fmt.Sprintf("moved from %s to %s", from, place.Name), part.Save(tx)
+	}, nil
+}
+
+func bulkCategoryAction(tx *sqlx.Tx, form map[string][]string) (bulkAction, error) {
+	category := new(Category)
+	err := tx.Get(category, `SELECT * FROM 'category' WHERE "id" = ?`,
+		first(form["category"]))
+	if err != nil {
+		return nil, err
+	}
+
+	return func(tx *sqlx.Tx, part *PartView) (string, error) {
+		if part.CategoryId == category.Id {
+			return "", nil
+		}
+		from := part.CategoryName
+		part.CategoryId = category.Id
+		return fmt.Sprintf("category changed from %s to %s", from, category.Name),
+			part.Save(tx)
+	}, nil
+}
+
+func bulkTagAction(tx *sqlx.Tx, form map[string][]string, add bool) (bulkAction, error) {
+	name := strings.TrimSpace(first(form["tag"]))
+	if name == "" {
+		return nil, fmt.Errorf("no tag given")
+	}
+
+	tag, err := findOrCreateTag(tx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(tx *sqlx.Tx, part *PartView) (string, error) {
+		var tagged int
+		err := tx.Get(&tagged, `SELECT COUNT(*) FROM 'part_tag'
+		WHERE "part_id" = ? AND "tag_id" = ?`, part.Id, tag.Id)
+		if err != nil || (tagged != 0) == add {
+			return "", err
+		}
+		if add {
+			return "tagged " + tag.Name, part.AddTag(tx, tag)
+		}
+		return "untagged " + tag.Name, part.RemoveTag(tx, tag)
+	}, nil
+}
+
+// bulkStockAction sets the stock of every part, the amount is parsed in the
+// unit of measure of each part
+func bulkStockAction(amount string) bulkAction {
+	return func(tx *sqlx.Tx, part *PartView) (string, error) {
+		unit := stockUnit(part.StockUnit)
+		value, err := ParseAmount(amount, unit)
+		if err != nil || part.Amount == value {
+			return "", err
+		}
+		partAmount := &PartAmount{
+			PartId:    part.Id,
+			Amount:    value,
+			Timestamp: time.Now(),
+		}
+		return fmt.Sprintf("stock changed from %s to %s",
+				formatStock(part.Amount, part.StockUnit),
+				formatStock(value, part.StockUnit)),
+			partAmount.Save(tx)
+	}
+}
+
+func bulkDeleteAction(tx *sqlx.Tx, part *PartView) (string, error) {
+	_, err := tx.Exec(`DELETE FROM 'part' WHERE "id" = ?`, part.Id)
+	return "deleted", err
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// BulkPartsHandler applies one action to all selected parts within a single
+// transaction and shows a summary of the changes
+func (app *Application) BulkPartsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	// Labels are rendered by the LabelsHandler
+	if r.PostForm.Get("action") == "labels" {
+		query := url.Values{
+			"layout": {r.PostForm.Get("layout")},
+			"parts":  r.PostForm["parts"],
+		}
+		http.Redirect(w, r, "/labels?"+query.Encode(), http.StatusSeeOther)
+		return
+	}
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	var action bulkAction
+	switch r.PostForm.Get("action") {
+	case "move":
+		action, err = bulkMoveAction(tx, r.PostForm)
+	case "category":
+		action, err = bulkCategoryAction(tx, r.PostForm)
+	case "tag_add":
+		action, err = bulkTagAction(tx, r.PostForm, true)
+	case "tag_remove":
+		action, err = bulkTagAction(tx, r.PostForm, false)
+	case "stock":
+		if r.PostForm.Get("amount") == "" {
+			err = errors.New("no stock given")
+		}
+		action = bulkStockAction(r.PostForm.Get("amount"))
+	case "empty":
+		action = bulkStockAction("0")
+	case "delete":
+		action = bulkDeleteAction
+	default:
+		err = fmt.Errorf("invalid bulk action %q", r.PostForm.Get("action"))
+	}
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	changes := []bulkChange{}
+	for _, id := range r.PostForm["parts"] {
+		part := new(PartView)
+		err = tx.Get(part, `SELECT * FROM 'part_view' WHERE "id" = ?`, id)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			app.Error(w, err)
+			return
+		}
+
+		change, err := action(tx, part)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+		if change != "" {
+			changes = append(changes, bulkChange{*part, change})
+		}
+	}
+
+	tx.Commit()
+
+	// Only local redirects are allowed
+	if next := r.PostForm.Get("next"); strings.HasPrefix(next, "/") &&
+		!strings.HasPrefix(next, "//") {
+		http.Redirect(w, r, next, http.StatusSeeOther)
+		return
+	}
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"Action":   r.PostForm.Get("action"),
+		"Selected": len(r.PostForm["parts"]),
+		"Changes":  changes,
+	}, "BulkResult", "Layout")
+}
@@ -0,0 +1,277 @@
+package inventory
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fritz0705/inventory/si"
+)
+
+// CalculatorResults is the number of combinations shown by the calculator
+var CalculatorResults = 10
+
+// calcValue is a distinct value in stock, together with the parts which have
+// this value
+type calcValue struct {
+	Value  float64
+	Amount float64
+	Parts  []PartView
+}
+
+type calcCombination struct {
+	// Kind is "single", "series", "parallel" or "divider". The values of a
+	// divider are the upper and the lower part.
+	Kind   string
+	Values []*calcValue
+	Result float64
+	// Error is the relative deviation from the target
+	Error float64
+}
+
+// ErrorPercent returns the relative error in percent
+func (c calcCombination) ErrorPercent() float64 {
+	return c.Error * 100
+}
+
+// calcResults keeps the best combinations, ordered by their error
+type calcResults struct {
+	target float64
+	best   []calcCombination
+}
+
+func (r *calcResults) add(kind string, result float64, values ...*calcValue) {
+	err := math.Abs(result-r.target) / math.Abs(r.target)
+	if len(r.best) == CalculatorResults && err >= r.best[len(r.best)-1].Error {
+		return
+	}
+	if !availableFor(values) {
+		return
+	}
+
+	combination := calcCombination{
+		Kind:   kind,
+		Values: append([]*calcValue(nil), values...),
+		Result: result,
+		Error:  err,
+	}
+	pos := sort.Search(len(r.best), func(i int) bool {
+		return r.best[i].Error > err
+	})
+	r.best = append(r.best, calcCombination{})
+	copy(r.best[pos+1:], r.best[pos:])
+	r.best[pos] = combination
+	if len(r.best) > CalculatorResults {
+		r.best = r.best[:CalculatorResults]
+	}
+}
+
+// availableFor reports whether there are enough parts in stock to use every
+// value as often as it occurs in values
+func availableFor(values []*calcValue) bool {
+	for _, value := range values {
+		var used float64
+		for _, other := range values {
+			if other == value {
+				used++
+			}
+		}
+		if used > value.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+func sumOf(values ...*calcValue) float64 {
+	res := 0.0
+	for _, value := range values {
+		res += value.Value
+	}
+	return res
+}
+
+func reciprocalSumOf(values ...*calcValue) float64 {
+	res := 0.0
+	for _, value := range values {
+		res += 1 / value.Value
+	}
+	return 1 / res
+}
+
+// addsInParallel reports whether values of the unit add up in parallel, like
+// capacitances, and not in series like resistances and inductances
+func addsInParallel(unit string) bool {
+	unit = si.NormalizeUnit(unit)
+	return unit == "F" || strings.EqualFold(unit, si.Units["F"])
+}
+
+// findCombinations returns the best series and parallel combinations of up
+// to count values. Values add up in series, or in parallel if inParallel is
+// set.
+func findCombinations(values []*calcValue, target float64, count int, inParallel bool) []calcCombination {
+	sum, reciprocalSum := "series", "parallel"
+	if inParallel {
+		sum, reciprocalSum = "parallel", "series"
+	}
+
+	results := &calcResults{target: target}
+	for i, a := range values {
+		results.add("single", a.Value, a)
+		for j := i; j < len(values); j++ {
+			b := values[j]
+			results.add(sum, sumOf(a, b), a, b)
+			results.add(reciprocalSum, reciprocalSumOf(a, b), a, b)
+			if count < 3 {
+				continue
+			}
+			for k := j; k < len(values); k++ {
+				c := values[k]
+				results.add(sum, sumOf(a, b, c), a, b, c)
+				results.add(reciprocalSum, reciprocalSumOf(a, b, c), a, b, c)
+			}
+		}
+	}
+	return results.best
+}
+
+// findDividers returns the best voltage dividers for the ratio of output to
+// input voltage. The voltages of capacitive dividers, whose values add up in
+// parallel, are inversely proportional to the values.
+func findDividers(values []*calcValue, ratio float64, inParallel bool) []calcCombination {
+	results := &calcResults{target: ratio}
+	for _, upper := range values {
+		for _, lower := range values {
+			if inParallel {
+				results.add("divider", upper.Value/(upper.Value+lower.Value), upper, lower)
+			} else {
+				results.add("divider", lower.Value/(upper.Value+lower.Value), upper, lower)
+			}
+		}
+	}
+	return results.best
+}
+
+// stockValues returns the distinct positive values of the parts in stock in
+// the given category
+func (app *Application) stockValues(categoryId int64) ([]*calcValue, error) {
+	parts := []PartView{}
+	err := app.DB.Select(&parts, `SELECT * FROM 'part_view'
+	WHERE "category_id" = ? AND "amount" > 0 AND "value" > 0
+	ORDER BY "value" ASC`, categoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	values := []*calcValue{}
+	byDecimal := make(map[string]*calcValue)
+	for _, part := range parts {
+		decimal := part.Decimal.String
+		value, ok := byDecimal[decimal]
+		if !ok {
+			value = &calcValue{Value: part.Value.Float64}
+			byDecimal[decimal] = value
+			values = append(values, value)
+		}
+		value.Amount += part.Amount
+		value.Parts = append(value.Parts, part)
+	}
+	return values, nil
+}
+
+func (app *Application) CalculatorHandler(w http.ResponseWriter, r *http.Request) {
+	categories := []Category{}
+	err := app.DB.Select(&categories, `SELECT * FROM 'category' ORDER BY "name" ASC`)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = "value"
+	}
+	count, _ := strconv.Atoi(r.FormValue("count"))
+	if count != 3 {
+		count = 2
+	}
+	categoryId, _ := strconv.ParseInt(r.FormValue("category"), 10, 64)
+
+	data := map[string]interface{}{
+		"Categories": categories,
+		"Category":   categoryId,
+		"Mode":       mode,
+		"Count":      count,
+		"Target":     r.FormValue("target"),
+		"Ratio":      r.FormValue("ratio"),
+		"Vin":        r.FormValue("vin"),
+		"Vout":       r.FormValue("vout"),
+	}
+
+	if categoryId == 0 {
+		app.renderTemplate(w, r, data, "Calculator", "Layout")
+		return
+	}
+
+	values, err := app.stockValues(categoryId)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	var inParallel bool
+	for _, category := range categories {
+		if category.Id == categoryId {
+			inParallel = addsInParallel(category.UnitSymbol.String) ||
+				addsInParallel(category.Unit.String)
+		}
+	}
+
+	var results []calcCombination
+	switch {
+	case mode == "value" && r.FormValue("target") != "":
+		target, err := si.Parse(r.FormValue("target"))
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+		if target.Value() <= 0 {
+			break
+		}
+		results = findCombinations(values, target.Value(), count, inParallel)
+	case mode == "divider" && (r.FormValue("ratio") != "" ||
+		r.FormValue("vin") != "" && r.FormValue("vout") != ""):
+		// The ratio is either given directly or by the voltages
+		var ratio float64
+		if r.FormValue("ratio") != "" {
+			num, err := si.Parse(r.FormValue("ratio"))
+			if err != nil {
+				app.Error(w, err)
+				return
+			}
+			ratio = num.Value()
+		} else {
+			vin, err := si.Parse(r.FormValue("vin"))
+			if err == nil {
+				var vout si.Number
+				vout, err = si.Parse(r.FormValue("vout"))
+				ratio = vout.Value() / vin.Value()
+			}
+			if err != nil {
+				app.Error(w, err)
+				return
+			}
+		}
+		if ratio <= 0 || ratio >= 1 || math.IsNaN(ratio) {
+			break
+		}
+		data["RatioValue"] = ratio
+		results = findDividers(values, ratio, inParallel)
+	}
+
+	data["Results"] = results
+	data["Values"] = len(values)
+	app.renderTemplate(w, r, data, "Calculator", "Layout")
+}
@@ -0,0 +1,93 @@
+package inventory
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFindCombinations(t *testing.T) {
+	type testCase struct {
+		Values     []float64
+		Target     float64
+		InParallel bool
+		Kind       string
+		Result     float64
+	}
+
+	testCases := []testCase{
+		testCase{[]float64{1000, 2200}, 3200, false, "series", 3200},
+		testCase{[]float64{1000, 2200}, 500, false, "parallel", 500},
+		testCase{[]float64{100e-9, 220e-9}, 320e-9, true, "parallel", 320e-9},
+		testCase{[]float64{100e-9, 220e-9}, 50e-9, true, "series", 50e-9},
+		testCase{[]float64{10e-6}, 10e-6, true, "single", 10e-6},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		values := []*calcValue{}
+		for _, value := range testCase.Values {
+			values = append(values, &calcValue{Value: value, Amount: 2})
+		}
+		res := findCombinations(values, testCase.Target, 2, testCase.InParallel)
+		if len(res) == 0 {
+			t.Error("Got no combination")
+			continue
+		}
+		if res[0].Kind != testCase.Kind ||
+			math.Abs(res[0].Result-testCase.Result) > 1e-9*testCase.Result {
+			t.Errorf("Got %s %v", res[0].Kind, res[0].Result)
+		}
+	}
+}
+
+func TestFindDividers(t *testing.T) {
+	type testCase struct {
+		InParallel bool
+		Upper      float64
+		Lower      float64
+	}
+
+	testCases := []testCase{
+		testCase{false, 1000, 2000},
+		testCase{true, 2000, 1000},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		values := []*calcValue{&calcValue{Value: 1000, Amount: 1}, &calcValue{Value: 2000, Amount: 1}}
+		res := findDividers(values, 2.0/3, testCase.InParallel)
+		if len(res) == 0 {
+			t.Error("Got no divider")
+			continue
+		}
+		if res[0].Values[0].Value != testCase.Upper || res[0].Values[1].Value != testCase.Lower {
+			t.Errorf("Got %v / %v", res[0].Values[0].Value, res[0].Values[1].Value)
+		}
+	}
+}
+
+func TestAddsInParallel(t *testing.T) {
+	type testCase struct {
+		Unit   string
+		Result bool
+	}
+
+	testCases := []testCase{
+		testCase{"F", true},
+		testCase{"farad", true},
+		testCase{"Ω", false},
+		testCase{"Ohm", false},
+		testCase{"H", false},
+		testCase{"", false},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := addsInParallel(testCase.Unit); res != testCase.Result {
+			t.Errorf("Got %v", res)
+		}
+	}
+}
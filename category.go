@@ -4,8 +4,22 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+
+	"github.com/fritz0705/inventory/si"
 )
 
+// DisplayPrefixes are the prefixes offered as display prefix of a category
+var DisplayPrefixes = []si.Prefix{
+	si.Giga,
+	si.Mega,
+	si.Kilo,
+	si.None,
+	si.Milli,
+	si.Micro,
+	si.Nano,
+	si.Pico,
+}
+
 func (app *Application) ListCategoriesHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "POST" {
 		app.CreateCategoryHandler(w, r)
@@ -32,6 +46,7 @@ func (app *Application) NewCategoryHandler(w http.ResponseWriter, r *http.Reques
 
 	app.renderTemplate(w, r, map[string]interface{}{
 		"Categories": categories,
+		"Prefixes":   DisplayPrefixes,
 	}, "NewCategory", "Layout")
 }
 
@@ -86,6 +101,7 @@ func (app *Application) EditCategoryHandler(w http.ResponseWriter, r *http.Reque
 	app.renderTemplate(w, r, map[string]interface{}{
 		"Category": category,
 		"Categories": categories,
+		"Prefixes": DisplayPrefixes,
 	}, "EditCategory", "Layout")
 }
 
@@ -0,0 +1,549 @@
+package inventory
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
+)
+
+// ImportFields are the fields of a part which can be read from a CSV column
+var ImportFields = []string{"name", "description", "value", "category", "place",
+	"amount", "distributor", "key"}
+
+// Actions of an ImportRow
+const (
+	ImportCreate    = "create"
+	ImportUpdate    = "update"
+	ImportDuplicate = "duplicate"
+	ImportError     = "error"
+)
+
+// ImportOptions controls ImportCSV
+type ImportOptions struct {
+	// Columns maps fields to column headers or to 1-based column numbers.
+	// Fields which are not mapped are read from the column named like the
+	// field, if there is one.
+	Columns map[string]string
+	// CreateCategories and CreatePlaces create unknown categories and places
+	// instead of rejecting the row
+	CreateCategories bool
+	CreatePlaces     bool
+}
+
+// ParseImportColumns parses a column mapping like "name=Part,value=3"
+func ParseImportColumns(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 || !isImportField(strings.TrimSpace(pair[0])) {
+			return nil, fmt.Errorf("invalid column mapping %q", item)
+		}
+		columns[strings.TrimSpace(pair[0])] = strings.TrimSpace(pair[1])
+	}
+	return columns, nil
+}
+
+func isImportField(field string) bool {
+	for _, f := range ImportFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// An ImportRow describes what happens to one line of the CSV data
+type ImportRow struct {
+	Line    int
+	Action  string
+	PartId  int64
+	Name    string
+	Changes []string
+	Err     error
+}
+
+// An ImportResult summarises an import
+type ImportResult struct {
+	Rows       []ImportRow
+	Created    int
+	Updated    int
+	Duplicates int
+	Errors     int
+	// Categories and Places are the names of the created categories and
+	// places
+	Categories []string
+	Places     []string
+}
+
+func (r *ImportResult) add(row ImportRow) {
+	switch row.Action {
+	case ImportCreate:
+		r.Created++
+	case ImportUpdate:
+		r.Updated++
+	case ImportDuplicate:
+		r.Duplicates++
+	case ImportError:
+		r.Errors++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+type partImporter struct {
+	tx      *sqlx.Tx
+	opts    *ImportOptions
+	result  *ImportResult
+	columns map[string]int
+	// categories, places and distributors map lower-case names to ids
+	categories   map[string]int64
+	places       map[string]int64
+	distributors map[string]int64
+	// seen maps the ids of imported parts to the line which imported them
+	seen map[int64]int
+}
+
+// ImportCSV imports parts from CSV data, whose first line contains the column
+// headers. Existing parts are matched by distributor key, or by name,
+// category and value, and are updated. Everything happens within tx, the
+// caller commits it or rolls it back for a dry-run. Distributor keys are
+// stored as given, they are not resolved by APIs.
+func ImportCSV(tx *sqlx.Tx, r io.Reader, opts *ImportOptions) (*ImportResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	im := &partImporter{
+		tx:     tx,
+		opts:   opts,
+		result: new(ImportResult),
+		seen:   make(map[int64]int),
+	}
+	im.columns, err = importColumns(header, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	im.categories, err = namedIds(tx, "category")
+	if err == nil {
+		im.places, err = namedIds(tx, "place")
+	}
+	if err == nil {
+		im.distributors, err = namedIds(tx, "distributor")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		im.result.add(im.importRow(line, record))
+	}
+
+	return im.result, nil
+}
+
+// importColumns resolves the column of every field in the header
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	byName := make(map[string]int)
+	for n, name := range header {
+		byName[strings.ToLower(strings.TrimSpace(name))] = n
+	}
+
+	columns := make(map[string]int)
+	for _, field := range ImportFields {
+		column, ok := mapping[field]
+		if !ok || column == "" {
+			if n, ok := byName[field]; ok {
+				columns[field] = n
+			}
+			continue
+		}
+		if n, err := strconv.Atoi(column); err == nil {
+			if n < 1 || n > len(header) {
+				return nil, fmt.Errorf("column %d of %s does not exist", n, field)
+			}
+			columns[field] = n - 1
+		} else if n, ok := byName[strings.ToLower(column)]; ok {
+			columns[field] = n
+		} else {
+			return nil, fmt.Errorf("column %q of %s does not exist", column, field)
+		}
+	}
+
+	if _, ok := columns["name"]; !ok {
+		return nil, errors.New("no column for the name")
+	}
+	return columns, nil
+}
+
+func namedIds(tx *sqlx.Tx, table string) (map[string]int64, error) {
+	rows, err := tx.Query(`SELECT "id", "name" FROM '` + table + `'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]int64)
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		ids[strings.ToLower(name)] = id
+	}
+	return ids, rows.Err()
+}
+
+// field returns the trimmed value of a field, and whether it has a column
+func (im *partImporter) field(record []string, field string) (string, bool) {
+	n, ok := im.columns[field]
+	if !ok {
+		return "", false
+	}
+	if n >= len(record) {
+		return "", true
+	}
+	return strings.TrimSpace(record[n]), true
+}
+
+func (im *partImporter) category(name string) (int64, error) {
+	if id, ok := im.categories[strings.ToLower(name)]; ok {
+		return id, nil
+	}
+	if !im.opts.CreateCategories {
+		return 0, fmt.Errorf("unknown category %q", name)
+	}
+
+	category := &Category{Name: name}
+	if err := category.Save(im.tx); err != nil {
+		return 0, err
+	}
+	im.categories[strings.ToLower(name)] = category.Id
+	im.result.Categories = append(im.result.Categories, name)
+	return category.Id, nil
+}
+
+func (im *partImporter) place(name string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+	if id, ok := im.places[strings.ToLower(name)]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+	if !im.opts.CreatePlaces {
+		return sql.NullInt64{}, fmt.Errorf("unknown place %q", name)
+	}
+
+	place := &Place{Name: name}
+	if err := place.Save(im.tx); err != nil {
+		return sql.NullInt64{}, err
+	}
+	im.places[strings.ToLower(name)] = place.Id
+	im.result.Places = append(im.result.Places, name)
+	return sql.NullInt64{Int64: place.Id, Valid: true}, nil
+}
+
+// match looks up the existing part of a row, it returns nil if there is none
+func (im *partImporter) match(record []string, form url.Values, categoryId, distributorId int64) (*Part, error) {
+	part := new(Part)
+	key, _ := im.field(record, "key")
+	if distributorId != 0 && key != "" {
+		err := im.tx.Get(part, `SELECT * FROM 'part' WHERE "id" IN
+		(SELECT "part_id" FROM 'distributor_part' WHERE "distributor_id" = ?
+		AND "key" = ?) LIMIT 1`, distributorId, key)
+		if err != sql.ErrNoRows {
+			return part, err
+		}
+	}
+
+	query := `SELECT * FROM 'part' WHERE "name" = ? AND "category_id" = ?`
+	args := []interface{}{form.Get("name"), categoryId}
+	if value, ok := form["value"]; ok {
+		var decimal interface{}
+		if value[0] != "" {
+			num, err := si.ParseQuantityBinary(value[0])
+			if err != nil && err != io.EOF {
+				return nil, err
+			}
+			decimal = num.Decimal()
+		}
+		query += ` AND "value_decimal" IS ?`
+		args = append(args, decimal)
+	}
+
+	err := im.tx.Get(part, query+` ORDER BY "id" ASC LIMIT 1`, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return part, err
+}
+
+func (im *partImporter) importRow(line int, record []string) (row ImportRow) {
+	row.Line = line
+	row.Name, _ = im.field(record, "name")
+	err := im.importPart(&row, record)
+	if err != nil {
+		row.Action = ImportError
+		row.Err = err
+	}
+	return
+}
+
+func (im *partImporter) importPart(row *ImportRow, record []string) error {
+	if row.Name == "" {
+		return errors.New("no name")
+	}
+
+	// The form fields are loaded by Part.LoadForm
+	form := url.Values{"name": {row.Name}}
+	for _, field := range []string{"description", "value"} {
+		if value, ok := im.field(record, field); ok {
+			form.Set(field, value)
+		}
+	}
+
+	categoryName, _ := im.field(record, "category")
+	if categoryName == "" {
+		return errors.New("no category")
+	}
+	categoryId, err := im.category(categoryName)
+	if err != nil {
+		return err
+	}
+
+	placeName, hasPlace := im.field(record, "place")
+	placeId, err := im.place(placeName)
+	if err != nil {
+		return err
+	}
+
+	var distributorId int64
+	if name, _ := im.field(record, "distributor"); name != "" {
+		var ok bool
+		distributorId, ok = im.distributors[strings.ToLower(name)]
+		if !ok {
+			return fmt.Errorf("unknown distributor %q", name)
+		}
+	}
+
+	part, err := im.match(record, form, categoryId, distributorId)
+	if err != nil {
+		return err
+	}
+
+	if part == nil {
+		row.Action = ImportCreate
+		part = &Part{
+			CategoryId: categoryId,
+			PlaceId:    placeId,
+			CreatedAt:  time.Now(),
+		}
+		err = part.LoadForm(form)
+		if err == nil {
+			err = part.CheckValue(im.tx, form.Get("value"))
+		}
+		if err == nil {
+			err = part.Save(im.tx)
+		}
+		if err != nil {
+			return err
+		}
+	} else if first, ok := im.seen[part.Id]; ok {
+		row.Action = ImportDuplicate
+		row.PartId = part.Id
+		row.Changes = append(row.Changes, fmt.Sprintf("already imported in line %d", first))
+		return nil
+	} else {
+		row.Action = ImportUpdate
+		updated := *part
+		err = updated.LoadForm(form)
+		if err == nil {
+			err = updated.CheckValue(im.tx, form.Get("value"))
+		}
+		if err != nil {
+			return err
+		}
+		if hasPlace && placeName != "" {
+			updated.PlaceId = placeId
+		}
+
+		if updated.Name != part.Name {
+			row.Changes = append(row.Changes, "name")
+		}
+		if updated.Value != part.Value && form.Get("value") != "" {
+			row.Changes = append(row.Changes, "value")
+		} else {
+			updated.Value = part.Value
+		}
+		if updated.Description != part.Description && form.Get("description") != "" {
+			row.Changes = append(row.Changes, "description")
+		} else {
+			updated.Description = part.Description
+		}
+		if updated.PlaceId != part.PlaceId {
+			row.Changes = append(row.Changes, "place")
+		}
+		if len(row.Changes) != 0 {
+			if err := updated.Save(im.tx); err != nil {
+				return err
+			}
+		}
+		part = &updated
+	}
+	row.PartId = part.Id
+	im.seen[part.Id] = row.Line
+
+	if err := im.importAmount(row, record, part); err != nil {
+		return err
+	}
+	if err := im.importDistributorPart(row, record, part, distributorId); err != nil {
+		return err
+	}
+
+	if row.Action == ImportUpdate && len(row.Changes) == 0 {
+		row.Action = ImportDuplicate
+	}
+	return nil
+}
+
+// importAmount records the stock of a row, if it differs from the latest one
+func (im *partImporter) importAmount(row *ImportRow, record []string, part *Part) error {
+	value, _ := im.field(record, "amount")
+	if value == "" && row.Action != ImportCreate {
+		return nil
+	}
+
+	var amount float64
+	if value != "" {
+		var err error
+		amount, err = ParseAmount(value, stockUnit(part.StockUnit))
+		if err != nil {
+			return err
+		}
+	}
+
+	latest, err := part.LatestAmount(im.tx)
+	if err != nil {
+		return err
+	}
+	if latest != nil && latest.Amount == amount {
+		return nil
+	}
+	if row.Action != ImportCreate {
+		row.Changes = append(row.Changes, "stock")
+	}
+
+	partAmount := &PartAmount{
+		PartId:    part.Id,
+		Amount:    amount,
+		Timestamp: time.Now(),
+	}
+	return partAmount.Save(im.tx)
+}
+
+// importDistributorPart links the part with the distributor key of a row
+func (im *partImporter) importDistributorPart(row *ImportRow, record []string, part *Part, distributorId int64) error {
+	key, _ := im.field(record, "key")
+	if distributorId == 0 || key == "" {
+		return nil
+	}
+
+	var count int64
+	err := im.tx.Get(&count, `SELECT COUNT(*) FROM 'distributor_part'
+	WHERE "distributor_id" = ? AND "part_id" = ? AND "key" = ?`,
+		distributorId, part.Id, key)
+	if err != nil || count != 0 {
+		return err
+	}
+	if row.Action != ImportCreate {
+		row.Changes = append(row.Changes, "distributor key")
+	}
+
+	distributorPart := &DistributorPart{
+		DistributorId: distributorId,
+		PartId:        part.Id,
+		Key:           key,
+	}
+	return distributorPart.Save(im.tx)
+}
+
+// ImportHandler shows the import form, and imports the uploaded CSV file. A
+// dry-run shows the preview, which carries the CSV data to import it for real.
+func (app *Application) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		app.renderTemplate(w, r, map[string]interface{}{
+			"Fields": ImportFields,
+			"Form":   url.Values{},
+		}, "Import", "Layout")
+		return
+	}
+
+	data := r.FormValue("data")
+	if file, _, err := r.FormFile("file"); err == nil {
+		defer file.Close()
+		buf, err := ioutil.ReadAll(file)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+		data = string(buf)
+	}
+
+	opts := &ImportOptions{
+		Columns:          make(map[string]string),
+		CreateCategories: r.FormValue("create_categories") != "",
+		CreatePlaces:     r.FormValue("create_places") != "",
+	}
+	for _, field := range ImportFields {
+		opts.Columns[field] = r.FormValue("column_" + field)
+	}
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	result, err := ImportCSV(tx, strings.NewReader(data), opts)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	dryRun := r.FormValue("dry_run") != ""
+	if !dryRun && result.Errors == 0 {
+		tx.Commit()
+	}
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"Fields":    ImportFields,
+		"Form":      r.Form,
+		"CSV":       data,
+		"Result":    result,
+		"Committed": !dryRun && result.Errors == 0,
+	}, "Import", "Layout")
+}
@@ -21,14 +21,19 @@ func (app *Application) outOfStockParts(tx *sqlx.Tx) ([]PartView, error) {
 func (app *Application) statisticsPanel(tx *sqlx.Tx) (map[string]interface{}, error) {
 	var (
 		totalParts      int64
-		totalStock      int64
 		emptyParts      int64
 		totalPlaces     int64
 		totalCategories int64
 	)
 
-	row := tx.QueryRowx(`SELECT COUNT(*), SUM(amount) FROM 'part_view'`)
-	if err := row.Scan(&totalParts, &totalStock); err != nil {
+	row := tx.QueryRowx(`SELECT COUNT(*) FROM 'part_view'`)
+	if err := row.Scan(&totalParts); err != nil {
+		return nil, err
+	}
+
+	// Only amounts of the same unit of measure can be summed up
+	totalStock, err := stockTotals(tx)
+	if err != nil {
 		return nil, err
 	}
 
@@ -78,9 +83,17 @@ func (app *Application) DashboardHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	userId, _ := app.currentUserId(r)
+	views, err := app.dashboardViews(tx, userId)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
 	app.renderTemplate(w, r, map[string]interface{}{
 		"Parts":      parts,
 		"OutOfStock": outOfStock,
 		"Statistics": statistics,
+		"Views":      views,
 	}, "Dashboard", "Layout")
 }
@@ -0,0 +1,22 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE IF NOT EXISTS 'saved_view' (
+	'id' INTEGER PRIMARY KEY,
+	'name' TEXT NOT NULL,
+	'kind' TEXT NOT NULL,
+	'query' TEXT NOT NULL,
+	'owner_id' INTEGER,
+	'shared' BOOLEAN NOT NULL DEFAULT 0,
+	'dashboard' BOOLEAN NOT NULL DEFAULT 0,
+	'created_at' DATETIME,
+	FOREIGN KEY('owner_id') REFERENCES 'user'('id') ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS 'saved_view_idx_owner_id' ON 'saved_view'('owner_id');
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE 'saved_view';
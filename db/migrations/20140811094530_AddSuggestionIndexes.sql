@@ -0,0 +1,18 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE INDEX IF NOT EXISTS 'part_idx_name_nocase' ON 'part' ('name' COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS 'place_idx_name_nocase' ON 'place' ('name' COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS 'category_idx_name_nocase' ON 'category' ('name' COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS 'part_amount_idx_part_id_timestamp' ON 'part_amount' (
+	'part_id', 'timestamp'
+);
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP INDEX 'part_idx_name_nocase';
+DROP INDEX 'place_idx_name_nocase';
+DROP INDEX 'category_idx_name_nocase';
+DROP INDEX 'part_amount_idx_part_id_timestamp';
@@ -0,0 +1,24 @@
+
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE IF NOT EXISTS 'tag' (
+	'id' INTEGER PRIMARY KEY,
+	'name' TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS 'part_tag' (
+	'part_id' INTEGER NOT NULL,
+	'tag_id' INTEGER NOT NULL,
+	PRIMARY KEY('part_id', 'tag_id'),
+	FOREIGN KEY('part_id') REFERENCES 'part'('id') ON DELETE CASCADE,
+	FOREIGN KEY('tag_id') REFERENCES 'tag'('id') ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS 'part_tag_idx_tag_id' ON 'part_tag'('tag_id');
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE 'part_tag';
+DROP TABLE 'tag';
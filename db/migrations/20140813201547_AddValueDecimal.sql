@@ -0,0 +1,40 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- 'value' keeps its INTEGER affinity, rebuilding 'part' would cascade to all
+-- tables referencing it. Values which were stored with rounding artifacts are
+-- normalised to 15 significant digits, and exact comparisons use the decimal
+-- representation in 'value_decimal', which is written in the same format by
+-- si.Number.Decimal.
+ALTER TABLE 'part' ADD COLUMN 'value_decimal' TEXT;
+
+UPDATE 'part' SET "value" = CAST(printf('%.15g', "value") AS REAL),
+	"value_decimal" = printf('%.15g', "value")
+	WHERE "value" IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS 'part_idx_value_decimal' ON 'part' (
+	'value_decimal'
+);
+
+DROP VIEW 'part_view';
+
+CREATE VIEW IF NOT EXISTS 'part_view' AS SELECT 'part'.*,
+	'category'."name" AS 'category_name',
+	'category'."unit" AS 'unit',
+	'category'."unit_symbol" AS 'unit_symbol',
+	'place'."name" AS 'place_name',
+	"part_amount" AS 'amount',
+	'attachment'.'key' AS 'image_key'
+	FROM 'part'
+	LEFT JOIN 'category' ON 'category'.'id' = 'part'.'category_id'
+	LEFT JOIN 'place' ON 'place'.'id' = 'part'.'place_id'
+	LEFT JOIN 'attachment' ON 'attachment'.'id' = 'part'.'image_id'
+	LEFT JOIN (SELECT "amount" AS 'part_amount',
+		"part_id" AS 'part_amount_part_id' FROM 'part_amount'
+		GROUP BY "part_amount_part_id"
+		ORDER BY "timestamp" DESC) ON "part_amount_part_id" = 'part'.'id';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP INDEX 'part_idx_value_decimal';
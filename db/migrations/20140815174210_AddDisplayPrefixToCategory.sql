@@ -0,0 +1,29 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- The exponent of the prefix used to show values of the category, NULL
+-- chooses the best matching prefix for each value
+ALTER TABLE 'category' ADD COLUMN 'display_prefix' INTEGER;
+
+DROP VIEW 'part_view';
+
+CREATE VIEW IF NOT EXISTS 'part_view' AS SELECT 'part'.*,
+	'category'."name" AS 'category_name',
+	'category'."unit" AS 'unit',
+	'category'."unit_symbol" AS 'unit_symbol',
+	'category'."display_prefix" AS 'display_prefix',
+	'place'."name" AS 'place_name',
+	"part_amount" AS 'amount',
+	'attachment'.'key' AS 'image_key'
+	FROM 'part'
+	LEFT JOIN 'category' ON 'category'.'id' = 'part'.'category_id'
+	LEFT JOIN 'place' ON 'place'.'id' = 'part'.'place_id'
+	LEFT JOIN 'attachment' ON 'attachment'.'id' = 'part'.'image_id'
+	LEFT JOIN (SELECT "amount" AS 'part_amount',
+		"part_id" AS 'part_amount_part_id' FROM 'part_amount'
+		GROUP BY "part_amount_part_id"
+		ORDER BY "timestamp" DESC) ON "part_amount_part_id" = 'part'.'id';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
@@ -0,0 +1,29 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- 'unit_mass' is the mass of a single part, 'tare_mass' the mass of its
+-- packaging, both in grams
+ALTER TABLE 'part' ADD COLUMN 'unit_mass' REAL;
+ALTER TABLE 'part' ADD COLUMN 'tare_mass' REAL;
+
+DROP VIEW 'part_view';
+
+CREATE VIEW IF NOT EXISTS 'part_view' AS SELECT 'part'.*,
+	'category'."name" AS 'category_name',
+	'category'."unit" AS 'unit',
+	'category'."unit_symbol" AS 'unit_symbol',
+	'category'."display_prefix" AS 'display_prefix',
+	'place'."name" AS 'place_name',
+	"part_amount" AS 'amount',
+	'attachment'.'key' AS 'image_key'
+	FROM 'part'
+	LEFT JOIN 'category' ON 'category'.'id' = 'part'.'category_id'
+	LEFT JOIN 'place' ON 'place'.'id' = 'part'.'place_id'
+	LEFT JOIN 'attachment' ON 'attachment'.'id' = 'part'.'image_id'
+	LEFT JOIN (SELECT "amount" AS 'part_amount',
+		"part_id" AS 'part_amount_part_id' FROM 'part_amount'
+		GROUP BY "part_amount_part_id"
+		ORDER BY "timestamp" DESC) ON "part_amount_part_id" = 'part'.'id';
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
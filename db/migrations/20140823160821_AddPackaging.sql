@@ -0,0 +1,18 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- 'quantity' is given in the stock unit of the part
+CREATE TABLE IF NOT EXISTS 'packaging' (
+	'id' INTEGER PRIMARY KEY,
+	'part_id' INTEGER NOT NULL,
+	'name' TEXT NOT NULL,
+	'quantity' REAL NOT NULL,
+	FOREIGN KEY('part_id') REFERENCES 'part'('id') ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS 'packaging_idx_part_id' ON 'packaging'('part_id');
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE 'packaging';
@@ -0,0 +1,15 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+-- Unit symbols are compared with the units of searches, which are normalised
+-- by si.NormalizeUnit
+UPDATE 'category' SET "unit_symbol" = 'Ω'
+WHERE "unit_symbol" IN ('Ohm', 'ohm', 'Ohms', 'ohms', 'Ω');
+UPDATE 'category' SET "unit_symbol" = 'µ' || substr("unit_symbol", 2)
+WHERE substr("unit_symbol", 1, 1) IN ('u', 'μ') AND substr("unit_symbol", 2) IN
+('Ω', 'F', 'H', 'V', 'A', 'W', 'Hz', 's', 'S', 'C', 'J', 'Wh', 'Ah', 'VA', '°C');
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+-- The original spellings of the unit symbols are not kept
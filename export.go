@@ -0,0 +1,185 @@
+package inventory
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/fritz0705/inventory/si"
+	"github.com/fritz0705/inventory/xlsx"
+	"github.com/jmoiron/sqlx"
+)
+
+// exportColumns are the column headers of exported parts lists
+var exportColumns = []string{"Id", "Name", "Description", "Value", "Unit",
+	"Tolerance", "Category", "Place", "Stock", "Stock unit", "Distributor keys",
+	"Prices"}
+
+// exportPart is a part with its distributor keys and prices, as selected by
+// exportQuery
+type exportPart struct {
+	PartView
+	DistributorKeys   sql.NullString `db:"distributor_keys"`
+	DistributorPrices sql.NullString `db:"distributor_prices"`
+}
+
+// exportQuery returns a query which selects the parts of part_view matching
+// the condition, together with their distributor keys and prices
+func exportQuery(where, order string) string {
+	return `SELECT 'part_view'.*,
+	(SELECT group_concat("name" || ': ' || "key", '; ') FROM 'distributor_part_view'
+		WHERE "part_id" = 'part_view'."id") AS 'distributor_keys',
+	(SELECT group_concat("name" || ': ' || "price", '; ') FROM 'distributor_part_view'
+		WHERE "part_id" = 'part_view'."id") AS 'distributor_prices'
+	FROM 'part_view' WHERE ` + where + ` ORDER BY ` + order
+}
+
+func (p *exportPart) record() []string {
+	value := ""
+	if p.Value.Valid {
+		value = si.New(p.Value.Float64).Canon().String()
+	}
+	return []string{
+		strconv.FormatInt(p.Id, 10),
+		p.Name,
+		p.Description.String,
+		value,
+		p.UnitSymbol.String,
+		p.Tolerance.String,
+		p.CategoryName,
+		p.PlaceName.String,
+		formatAmount(p.Amount),
+		stockUnit(p.StockUnit),
+		p.DistributorKeys.String,
+		p.DistributorPrices.String,
+	}
+}
+
+// recordWriter is implemented by csv.Writer and xlsx.Writer
+type recordWriter interface {
+	Write(record []string) error
+}
+
+// ExportFormats maps the export formats to their content types
+var ExportFormats = map[string]string{
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportURLs returns the URLs of all export formats of a page
+func exportURLs(path string, base url.Values) map[string]template.URL {
+	urls := make(map[string]template.URL)
+	for format := range ExportFormats {
+		values := make(url.Values)
+		for k, v := range base {
+			values[k] = v
+		}
+		values.Set("format", format)
+		urls[format] = template.URL(path + "?" + values.Encode())
+	}
+	return urls
+}
+
+// exportParts streams the parts selected by query as CSV or XLSX file
+func (app *Application) exportParts(w http.ResponseWriter, r *http.Request, name string, query string, args []interface{}) {
+	format := r.FormValue("format")
+	contentType, ok := ExportFormats[format]
+	if !ok {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	rows, err := app.DB.Queryx(query, args...)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.`+format+`"`)
+
+	var (
+		writer recordWriter
+		flush  func() error
+	)
+	switch format {
+	case "csv":
+		csvWriter := csv.NewWriter(w)
+		writer, flush = csvWriter, func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	case "xlsx":
+		xlsxWriter := xlsx.NewWriter(w)
+		xlsxWriter.SheetName = name
+		writer, flush = xlsxWriter, xlsxWriter.Close
+	}
+
+	// The response has already begun, so errors can only be logged
+	err = writeExport(writer, rows)
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		log.Printf("export %s: %v", name, err)
+	}
+}
+
+func writeExport(writer recordWriter, rows *sqlx.Rows) error {
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+	for rows.Next() {
+		part := new(exportPart)
+		if err := rows.StructScan(part); err != nil {
+			return err
+		}
+		if err := writer.Write(part.record()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ExportPartsHandler exports the parts list with the filter and order of the
+// list, but without pagination
+func (app *Application) ExportPartsHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	filter, err := loadPartsFilter(r.Form)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	page := loadPartsPage(r.Form)
+
+	dir := "ASC"
+	if page.Desc {
+		dir = "DESC"
+	}
+	where, args := filter.SQL()
+	order := partsSortKeys[page.Sort] + ` ` + dir + `, "id" ` + dir
+
+	app.exportParts(w, r, "parts", exportQuery(where, order), args)
+}
+
+// ExportSearchHandler exports the results of a search query
+func (app *Application) ExportSearchHandler(w http.ResponseWriter, r *http.Request) {
+	sq, err := parseSearchQuery(r.FormValue("query"))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	where, args := sq.Where()
+	app.exportParts(w, r, "search", exportQuery(where, `"id" ASC`), args)
+}
@@ -0,0 +1,155 @@
+// Package importer reads the exports of other inventory tools into a common
+// model, which is written into the database by inventory.ImportInventory.
+// Everything which can not be converted is recorded as Problem.
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fritz0705/inventory/si"
+)
+
+// An Inventory is the content of an export
+type Inventory struct {
+	Parts    []*Part
+	Problems []Problem
+}
+
+// A Part is a part with its category and place. Categories are given as path
+// from the root category, places as flat name. The value is read from the
+// parameter named like one of ValueParameters.
+type Part struct {
+	Name         string
+	Description  string
+	Value        sql.NullFloat64
+	Category     []string
+	Place        string
+	Stock        float64
+	Distributors []Distributor
+	Attachments  []Attachment
+}
+
+// A Distributor is an order number of a part
+type Distributor struct {
+	Name  string
+	Key   string
+	Price float64
+}
+
+// An Attachment is a file of a part. The path is relative to the directory
+// of the files of the export.
+type Attachment struct {
+	Name string
+	Type string
+	Path string
+}
+
+// A Problem is something which could not be converted
+type Problem struct {
+	Item   string
+	Reason string
+}
+
+func (p Problem) String() string {
+	return p.Item + ": " + p.Reason
+}
+
+func (inv *Inventory) problem(item string, format string, args ...interface{}) {
+	inv.Problems = append(inv.Problems, Problem{item, fmt.Sprintf(format, args...)})
+}
+
+// ValueParameters are the lower-case names of the parameters which are read
+// as value of a part
+var ValueParameters = []string{"value", "resistance", "capacitance", "inductance"}
+
+func isValueParameter(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, n := range ValueParameters {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue parses the value of a part, like "4.7kΩ", values which can not be
+// parsed are recorded as problem
+func (inv *Inventory) setValue(part *Part, value string) {
+	q, err := si.ParseQuantityBinary(value)
+	if err != nil {
+		inv.problem("part "+part.Name, "value %q can not be parsed", value)
+		return
+	}
+	part.Value = sql.NullFloat64{Float64: q.Value(), Valid: true}
+}
+
+// A Reader reads an export
+type Reader func(r io.Reader) (*Inventory, error)
+
+// Formats are the readers of the supported exports by name
+var Formats = map[string]Reader{
+	"inventree": ReadInvenTree,
+	"partkeepr": ReadPartKeepr,
+	"partdb":    ReadPartDB,
+}
+
+// FormatNames returns the names of the supported formats in order
+func FormatNames() []string {
+	names := make([]string, 0, len(Formats))
+	for name := range Formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// number is a JSON number which may also be encoded as string, like decimals
+// of Django and Doctrine
+type number float64
+
+func (n *number) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+	*n = number(val)
+	return nil
+}
+
+// String formats a number without exponent
+func (n number) String() string {
+	return strconv.FormatFloat(float64(n), 'f', -1, 64)
+}
+
+// splitPath splits a path like "Passive > Resistors" and drops empty
+// elements
+func splitPath(path, sep string) []string {
+	res := []string{}
+	for _, name := range strings.Split(path, sep) {
+		if name = strings.TrimSpace(name); name != "" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+// placeName is the flat name of a place path, as places have no hierarchy
+func placeName(path []string) string {
+	return strings.Join(path, " / ")
+}
+
+func decode(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	return decoder.Decode(v)
+}
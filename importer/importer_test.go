@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"database/sql"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const invenTreeData = `[
+{"model": "part.partcategory", "pk": 1, "fields": {"name": "Passive", "parent": null}},
+{"model": "part.partcategory", "pk": 2, "fields": {"name": "Resistors", "parent": 1}},
+{"model": "stock.stocklocation", "pk": 1, "fields": {"name": "Shelf", "parent": null}},
+{"model": "stock.stocklocation", "pk": 2, "fields": {"name": "Box 1", "parent": 1}},
+{"model": "stock.stocklocation", "pk": 3, "fields": {"name": "Box 2", "parent": 1}},
+{"model": "company.company", "pk": 1, "fields": {"name": "Reichelt Elektronik"}},
+{"model": "part.part", "pk": 1, "fields": {"name": "R 10k", "description": "Metal film", "category": 2}},
+{"model": "stock.stockitem", "pk": 1, "fields": {"part": 1, "location": 2, "quantity": "10.00000"}},
+{"model": "stock.stockitem", "pk": 2, "fields": {"part": 1, "location": 3, "quantity": 90}},
+{"model": "company.supplierpart", "pk": 1, "fields": {"part": 1, "supplier": 1, "SKU": "METALL 10K"}},
+{"model": "company.supplierpricebreak", "pk": 1, "fields": {"part": 1, "quantity": 100, "price": "0.05"}},
+{"model": "company.supplierpricebreak", "pk": 2, "fields": {"part": 1, "quantity": 1, "price": "0.08"}},
+{"model": "part.partattachment", "pk": 1, "fields": {"part": 1, "attachment": "part_files/1/ds.pdf"}},
+{"model": "build.build", "pk": 1, "fields": {}}
+]`
+
+const partKeeprData = `{"@context": "/api/contexts/Part", "hydra:member": [
+{"name": "R 10k", "description": "Metal film",
+ "category": {"name": "Resistors", "categoryPath": "Root Category ➤ Passive ➤ Resistors"},
+ "storageLocation": {"name": "Box 2"}, "stockLevel": 100,
+ "partDistributors": [{"distributor": {"name": "Reichelt Elektronik"}, "orderNumber": "METALL 10K", "price": "0.0800"}],
+ "attachments": [{"originalFilename": "ds.pdf", "filename": "5a1b", "mimetype": "application/pdf"}],
+ "parameters": [{"name": "Resistance", "value": 10, "siPrefix": {"symbol": "k"}, "unit": {"symbol": "Ω"}},
+  {"name": "Power"}]}
+]}`
+
+const partDBData = `[
+{"name": "R 10k", "description": "Metal film",
+ "category": {"name": "Resistors", "full_path": "Passive → Resistors"},
+ "partLots": [
+  {"amount": 10, "storage_location": {"name": "Box 1", "full_path": "Shelf → Box 1"}},
+  {"amount": 90, "storage_location": {"name": "Box 2", "full_path": "Shelf → Box 2"}}],
+ "orderdetails": [{"supplier": {"name": "Reichelt Elektronik"}, "supplierpartnr": "METALL 10K",
+  "pricedetails": [{"price": "8.00", "price_related_quantity": 100, "min_discount_quantity": 1},
+   {"price": "5.00", "price_related_quantity": 100, "min_discount_quantity": 100}]}],
+ "attachments": [{"name": "ds.pdf", "path": "%MEDIA%/part/ds.pdf"}, {"name": "Web", "path": "https://example.com"}],
+ "parameters": [{"name": "Value", "value_typical": null, "value_text": "10k", "unit": ""},
+  {"name": "Tolerance", "value_text": "1%"}],
+ "footprint": null}
+]`
+
+func TestRead(t *testing.T) {
+	type testCase struct {
+		Format   string
+		Data     string
+		Part     Part
+		Problems []string
+	}
+
+	testCases := []testCase{
+		testCase{"inventree", invenTreeData, Part{
+			Name:         "R 10k",
+			Description:  "Metal film",
+			Category:     []string{"Passive", "Resistors"},
+			Place:        "Shelf / Box 2",
+			Stock:        100,
+			Distributors: []Distributor{{"Reichelt Elektronik", "METALL 10K", 0.08}},
+			Attachments:  []Attachment{{"ds.pdf", "", "part_files/1/ds.pdf"}},
+		}, []string{
+			"part R 10k: stock in 2 locations, the place is Shelf / Box 2",
+			"build.build: 1 records are not supported",
+		}},
+		testCase{"partkeepr", partKeeprData, Part{
+			Name:         "R 10k",
+			Description:  "Metal film",
+			Category:     []string{"Passive", "Resistors"},
+			Place:        "Box 2",
+			Stock:        100,
+			Distributors: []Distributor{{"Reichelt Elektronik", "METALL 10K", 0.08}},
+			Attachments:  []Attachment{{"ds.pdf", "application/pdf", "5a1b"}},
+			Value:        sql.NullFloat64{Float64: 10000, Valid: true},
+		}, []string{
+			"part R 10k: parameter Power is not supported",
+		}},
+		testCase{"partdb", partDBData, Part{
+			Name:         "R 10k",
+			Description:  "Metal film",
+			Category:     []string{"Passive", "Resistors"},
+			Place:        "Shelf / Box 2",
+			Stock:        100,
+			Distributors: []Distributor{{"Reichelt Elektronik", "METALL 10K", 0.08}},
+			Attachments:  []Attachment{{"ds.pdf", "", "part/ds.pdf"}},
+			Value:        sql.NullFloat64{Float64: 10000, Valid: true},
+		}, []string{
+			"part R 10k: stock in 2 locations, the place is Shelf / Box 2",
+			"part R 10k: external attachment https://example.com is not imported",
+			"part R 10k: parameter Tolerance is not supported",
+		}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.Format)
+
+		inv, err := Formats[testCase.Format](strings.NewReader(testCase.Data))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(inv.Parts) != 1 {
+			t.Errorf("Got %d parts, wants 1", len(inv.Parts))
+			continue
+		}
+		if !reflect.DeepEqual(*inv.Parts[0], testCase.Part) {
+			t.Errorf("Got %+v, wants %+v", *inv.Parts[0], testCase.Part)
+		}
+
+		problems := []string{}
+		for _, problem := range inv.Problems {
+			problems = append(problems, problem.String())
+		}
+		if !reflect.DeepEqual(problems, testCase.Problems) {
+			t.Errorf("Got problems %q, wants %q", problems, testCase.Problems)
+		}
+	}
+}
+
+func TestReadValue(t *testing.T) {
+	type testCase struct {
+		Format   string
+		Data     string
+		Value    sql.NullFloat64
+		Problems int
+	}
+
+	testCases := []testCase{
+		testCase{"partkeepr", `[{"name": "C", "parameters": [{"name": "Capacitance", "value": 100, "siPrefix": {"symbol": "n"}, "unit": {"symbol": "F"}}]}]`,
+			sql.NullFloat64{Float64: 100e-9, Valid: true}, 0},
+		testCase{"partkeepr", `[{"name": "R", "parameters": [{"name": "value", "value": null, "stringValue": "4k7"}]}]`,
+			sql.NullFloat64{Float64: 4700, Valid: true}, 0},
+		testCase{"partkeepr", `[{"name": "R", "parameters": [{"name": "Value", "value": null, "stringValue": ""}]}]`,
+			sql.NullFloat64{}, 0},
+		testCase{"partdb", `[{"name": "L", "parameters": [{"name": "Inductance", "value_typical": 2.2, "unit": "µH"}]}]`,
+			sql.NullFloat64{Float64: 2.2e-6, Valid: true}, 0},
+		testCase{"partdb", `[{"name": "R", "parameters": [{"name": "Value", "value_text": "ten"}]}]`,
+			sql.NullFloat64{}, 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		inv, err := Formats[testCase.Format](strings.NewReader(testCase.Data))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		value := inv.Parts[0].Value
+		if value.Valid != testCase.Value.Valid ||
+			math.Abs(value.Float64-testCase.Value.Float64) > 1e-9*math.Abs(testCase.Value.Float64) {
+			t.Errorf("Got %v", value)
+		}
+		if len(inv.Problems) != testCase.Problems {
+			t.Errorf("Got problems %v", inv.Problems)
+		}
+	}
+}
+
+func TestSplitPath(t *testing.T) {
+	type testCase struct {
+		Path   string
+		Sep    string
+		Result []string
+	}
+
+	testCases := []testCase{
+		testCase{"Root Category ➤ Passive ➤ Resistors", "➤", []string{"Root Category", "Passive", "Resistors"}},
+		testCase{"Passive → → Resistors ", "→", []string{"Passive", "Resistors"}},
+		testCase{"", "→", []string{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res := splitPath(testCase.Path, testCase.Sep)
+		if !reflect.DeepEqual(res, testCase.Result) {
+			t.Errorf("Got %q, wants %q", res, testCase.Result)
+		}
+	}
+}
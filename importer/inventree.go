@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+// djangoRecord is a record of a Django fixture, as written by InvenTree's
+// export-records task
+type djangoRecord struct {
+	Model  string          `json:"model"`
+	Pk     int64           `json:"pk"`
+	Fields json.RawMessage `json:"fields"`
+}
+
+type invenTreeTree struct {
+	Name   string `json:"name"`
+	Parent *int64 `json:"parent"`
+}
+
+type invenTreePart struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    *int64 `json:"category"`
+}
+
+type invenTreeStockItem struct {
+	Part     int64  `json:"part"`
+	Location *int64 `json:"location"`
+	Quantity number `json:"quantity"`
+}
+
+type invenTreeSupplierPart struct {
+	Part     int64  `json:"part"`
+	Supplier int64  `json:"supplier"`
+	SKU      string `json:"SKU"`
+}
+
+type invenTreePriceBreak struct {
+	Part     int64  `json:"part"`
+	Quantity number `json:"quantity"`
+	Price    number `json:"price"`
+}
+
+type invenTreeAttachment struct {
+	Part       int64  `json:"part"`
+	Attachment string `json:"attachment"`
+	Link       string `json:"link"`
+}
+
+type invenTreeCompany struct {
+	Name string `json:"name"`
+}
+
+// ReadInvenTree reads the JSON records of an InvenTree database export.
+// Categories keep their hierarchy, stock locations become places named by
+// their path. The stock of a part is the sum of its stock items, its place
+// is the location of the largest one.
+func ReadInvenTree(r io.Reader) (*Inventory, error) {
+	records := []djangoRecord{}
+	if err := decode(r, &records); err != nil {
+		return nil, err
+	}
+
+	var (
+		inv           = new(Inventory)
+		categories    = make(map[int64]*invenTreeTree)
+		locations     = make(map[int64]*invenTreeTree)
+		companies     = make(map[int64]string)
+		parts         = make(map[int64]*Part)
+		partCategory  = make(map[int64]int64)
+		partIds       = []int64{}
+		stockItems    = []invenTreeStockItem{}
+		supplierParts = make(map[int64]invenTreeSupplierPart)
+		supplierIds   = []int64{}
+		priceBreaks   = []invenTreePriceBreak{}
+		attachments   = []invenTreeAttachment{}
+		skipped       = make(map[string]int)
+	)
+
+	for _, record := range records {
+		var err error
+		switch record.Model {
+		case "part.partcategory":
+			category := new(invenTreeTree)
+			err = json.Unmarshal(record.Fields, category)
+			categories[record.Pk] = category
+		case "stock.stocklocation":
+			location := new(invenTreeTree)
+			err = json.Unmarshal(record.Fields, location)
+			locations[record.Pk] = location
+		case "company.company":
+			company := invenTreeCompany{}
+			err = json.Unmarshal(record.Fields, &company)
+			companies[record.Pk] = company.Name
+		case "part.part":
+			part := invenTreePart{}
+			err = json.Unmarshal(record.Fields, &part)
+			parts[record.Pk] = &Part{
+				Name:        part.Name,
+				Description: part.Description,
+			}
+			if part.Category != nil {
+				partCategory[record.Pk] = *part.Category
+			}
+			partIds = append(partIds, record.Pk)
+		case "stock.stockitem":
+			item := invenTreeStockItem{}
+			err = json.Unmarshal(record.Fields, &item)
+			stockItems = append(stockItems, item)
+		case "company.supplierpart":
+			supplierPart := invenTreeSupplierPart{}
+			err = json.Unmarshal(record.Fields, &supplierPart)
+			supplierParts[record.Pk] = supplierPart
+			supplierIds = append(supplierIds, record.Pk)
+		case "company.supplierpricebreak":
+			priceBreak := invenTreePriceBreak{}
+			err = json.Unmarshal(record.Fields, &priceBreak)
+			priceBreaks = append(priceBreaks, priceBreak)
+		case "part.partattachment":
+			attachment := invenTreeAttachment{}
+			err = json.Unmarshal(record.Fields, &attachment)
+			attachments = append(attachments, attachment)
+		default:
+			skipped[record.Model]++
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %d: %v", record.Model, record.Pk, err)
+		}
+	}
+
+	// The categories are resolved after all categories are read
+	for _, id := range partIds {
+		category, ok := partCategory[id]
+		if !ok {
+			continue
+		}
+		part := parts[id]
+		part.Category = invenTreePath(categories, category)
+		if len(part.Category) == 0 {
+			inv.problem("part "+part.Name, "unknown category %d", category)
+		}
+	}
+
+	largest := make(map[int64]float64)
+	places := make(map[int64]map[string]bool)
+	for _, item := range stockItems {
+		part, ok := parts[item.Part]
+		if !ok {
+			inv.problem(fmt.Sprintf("stock item of part %d", item.Part), "unknown part")
+			continue
+		}
+		part.Stock += float64(item.Quantity)
+		if item.Location == nil {
+			continue
+		}
+		place := placeName(invenTreePath(locations, *item.Location))
+		if places[item.Part] == nil {
+			places[item.Part] = make(map[string]bool)
+		}
+		places[item.Part][place] = true
+		if float64(item.Quantity) > largest[item.Part] || part.Place == "" {
+			part.Place, largest[item.Part] = place, float64(item.Quantity)
+		}
+	}
+	for _, id := range partIds {
+		if len(places[id]) > 1 {
+			inv.problem("part "+parts[id].Name, "stock in %d locations, the place is %s",
+				len(places[id]), parts[id].Place)
+		}
+	}
+
+	// The price of a supplier part is the price of its smallest price break
+	prices := make(map[int64]invenTreePriceBreak)
+	for _, priceBreak := range priceBreaks {
+		if price, ok := prices[priceBreak.Part]; !ok || priceBreak.Quantity < price.Quantity {
+			prices[priceBreak.Part] = priceBreak
+		}
+	}
+	for _, id := range supplierIds {
+		supplierPart := supplierParts[id]
+		part, ok := parts[supplierPart.Part]
+		if !ok {
+			inv.problem("supplier part "+supplierPart.SKU, "unknown part")
+			continue
+		}
+		part.Distributors = append(part.Distributors, Distributor{
+			Name:  companies[supplierPart.Supplier],
+			Key:   supplierPart.SKU,
+			Price: float64(prices[id].Price),
+		})
+	}
+
+	for _, attachment := range attachments {
+		part, ok := parts[attachment.Part]
+		if !ok {
+			inv.problem("attachment "+attachment.Attachment, "unknown part")
+			continue
+		}
+		if attachment.Attachment == "" {
+			inv.problem("part "+part.Name, "link %s is not an attachment", attachment.Link)
+			continue
+		}
+		part.Attachments = append(part.Attachments, Attachment{
+			Name: path.Base(attachment.Attachment),
+			Path: attachment.Attachment,
+		})
+	}
+
+	for _, id := range partIds {
+		inv.Parts = append(inv.Parts, parts[id])
+	}
+
+	models := make([]string, 0, len(skipped))
+	for model := range skipped {
+		models = append(models, model)
+	}
+	sort.Strings(models)
+	for _, model := range models {
+		inv.problem(model, "%d records are not supported", skipped[model])
+	}
+
+	return inv, nil
+}
+
+// invenTreePath returns the names from the root to the node with the id
+func invenTreePath(nodes map[int64]*invenTreeTree, id int64) []string {
+	path := []string{}
+	seen := make(map[int64]bool)
+	for {
+		node, ok := nodes[id]
+		if !ok || seen[id] {
+			break
+		}
+		seen[id] = true
+		path = append([]string{node.Name}, path...)
+		if node.Parent == nil {
+			break
+		}
+		id = *node.Parent
+	}
+	return path
+}
@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+	"path"
+	"strings"
+)
+
+// partDBSeparator separates the elements of the full paths of Part-DB
+const partDBSeparator = "→"
+
+type partDBTree struct {
+	Name     string `json:"name"`
+	FullPath string `json:"full_path"`
+}
+
+// path returns the path of the node, the name if there is no full path
+func (t *partDBTree) path() []string {
+	if t == nil {
+		return nil
+	}
+	if path := splitPath(t.FullPath, partDBSeparator); len(path) != 0 {
+		return path
+	}
+	return splitPath(t.Name, partDBSeparator)
+}
+
+type partDBPart struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Comment     string      `json:"comment"`
+	Category    *partDBTree `json:"category"`
+	PartLots    []struct {
+		Amount          number      `json:"amount"`
+		InstockUnknown  bool        `json:"instock_unknown"`
+		StorageLocation *partDBTree `json:"storage_location"`
+	} `json:"partLots"`
+	Orderdetails []struct {
+		Supplier *struct {
+			Name string `json:"name"`
+		} `json:"supplier"`
+		SupplierPartNr string `json:"supplierpartnr"`
+		Pricedetails   []struct {
+			Price                number `json:"price"`
+			PriceRelatedQuantity number `json:"price_related_quantity"`
+			MinDiscountQuantity  number `json:"min_discount_quantity"`
+		} `json:"pricedetails"`
+	} `json:"orderdetails"`
+	Attachments []struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"attachments"`
+	Parameters []struct {
+		Name         string  `json:"name"`
+		ValueTypical *number `json:"value_typical"`
+		ValueText    string  `json:"value_text"`
+		Unit         string  `json:"unit"`
+	} `json:"parameters"`
+	Manufacturer json.RawMessage `json:"manufacturer"`
+	Footprint    json.RawMessage `json:"footprint"`
+}
+
+// partDBPlaceholders are the prefixes of attachment paths, they refer to the
+// media directories of Part-DB
+var partDBPlaceholders = []string{"%MEDIA%/", "%SECURE%/", "%BASE%/"}
+
+// ReadPartDB reads the JSON export of the parts of Part-DB. The place of a
+// part is the location of its largest part lot, the stock is the sum of all
+// lots. Attachment paths are relative to the media directory.
+func ReadPartDB(r io.Reader) (*Inventory, error) {
+	parts := []partDBPart{}
+	if err := decode(r, &parts); err != nil {
+		return nil, err
+	}
+
+	inv := new(Inventory)
+	for _, p := range parts {
+		part := &Part{
+			Name:        p.Name,
+			Description: p.Description,
+			Category:    p.Category.path(),
+		}
+		if part.Description == "" {
+			part.Description = p.Comment
+		}
+
+		var largest float64
+		places := make(map[string]bool)
+		for _, lot := range p.PartLots {
+			if lot.InstockUnknown {
+				inv.problem("part "+part.Name, "a part lot has unknown stock")
+			}
+			part.Stock += float64(lot.Amount)
+			place := placeName(lot.StorageLocation.path())
+			if place == "" {
+				continue
+			}
+			places[place] = true
+			if float64(lot.Amount) > largest || part.Place == "" {
+				part.Place, largest = place, float64(lot.Amount)
+			}
+		}
+		if len(places) > 1 {
+			inv.problem("part "+part.Name, "stock in %d locations, the place is %s",
+				len(places), part.Place)
+		}
+
+		for _, o := range p.Orderdetails {
+			if o.Supplier == nil {
+				inv.problem("part "+part.Name, "order number %s has no supplier", o.SupplierPartNr)
+				continue
+			}
+			// The price is the unit price of the smallest quantity
+			distributor := Distributor{Name: o.Supplier.Name, Key: o.SupplierPartNr}
+			minimum := -1.0
+			for _, price := range o.Pricedetails {
+				if minimum >= 0 && float64(price.MinDiscountQuantity) >= minimum {
+					continue
+				}
+				minimum = float64(price.MinDiscountQuantity)
+				distributor.Price = float64(price.Price)
+				if price.PriceRelatedQuantity > 0 {
+					distributor.Price /= float64(price.PriceRelatedQuantity)
+				}
+			}
+			part.Distributors = append(part.Distributors, distributor)
+		}
+
+		for _, a := range p.Attachments {
+			if strings.Contains(a.Path, "://") {
+				inv.problem("part "+part.Name, "external attachment %s is not imported", a.Path)
+				continue
+			}
+			file := a.Path
+			for _, placeholder := range partDBPlaceholders {
+				file = strings.TrimPrefix(file, placeholder)
+			}
+			name := a.Name
+			if name == "" {
+				name = path.Base(file)
+			}
+			part.Attachments = append(part.Attachments, Attachment{
+				Name: name,
+				Path: file,
+			})
+		}
+
+		// The typical value of a parameter is given in its unit, which may
+		// have a SI prefix
+		for _, param := range p.Parameters {
+			if !isValueParameter(param.Name) {
+				inv.problem("part "+part.Name, "parameter %s is not supported", param.Name)
+				continue
+			}
+			value := param.ValueText
+			if param.ValueTypical != nil {
+				value = param.ValueTypical.String() + param.Unit
+			}
+			if value != "" {
+				inv.setValue(part, value)
+			}
+		}
+		if len(p.Manufacturer) != 0 && string(p.Manufacturer) != "null" {
+			inv.problem("part "+part.Name, "manufacturers are not supported")
+		}
+		if len(p.Footprint) != 0 && string(p.Footprint) != "null" {
+			inv.problem("part "+part.Name, "footprints are not supported")
+		}
+
+		inv.Parts = append(inv.Parts, part)
+	}
+
+	return inv, nil
+}
@@ -0,0 +1,150 @@
+package importer
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// partKeeprRoot is the root category of PartKeepr, it is dropped from the
+// category paths
+const partKeeprRoot = "Root Category"
+
+type partKeeprCollection struct {
+	Members []partKeeprPart `json:"hydra:member"`
+}
+
+type partKeeprPart struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Comment     string `json:"comment"`
+	Category    *struct {
+		Name         string `json:"name"`
+		CategoryPath string `json:"categoryPath"`
+	} `json:"category"`
+	StorageLocation *struct {
+		Name string `json:"name"`
+	} `json:"storageLocation"`
+	StockLevel       number `json:"stockLevel"`
+	PartDistributors []struct {
+		Distributor *struct {
+			Name string `json:"name"`
+		} `json:"distributor"`
+		OrderNumber string `json:"orderNumber"`
+		Price       number `json:"price"`
+	} `json:"partDistributors"`
+	Attachments []struct {
+		OriginalFilename string `json:"originalFilename"`
+		Filename         string `json:"filename"`
+		Mimetype         string `json:"mimetype"`
+	} `json:"attachments"`
+	PartParameters []struct {
+		Name        string  `json:"name"`
+		Value       *number `json:"value"`
+		StringValue string  `json:"stringValue"`
+		SiPrefix    *struct {
+			Symbol string `json:"symbol"`
+		} `json:"siPrefix"`
+		Unit *struct {
+			Symbol string `json:"symbol"`
+		} `json:"unit"`
+	} `json:"parameters"`
+	Manufacturers []json.RawMessage `json:"manufacturers"`
+	Footprint     json.RawMessage   `json:"footprint"`
+}
+
+// ReadPartKeepr reads the parts collection of the PartKeepr API (/api/parts)
+// in JSON-LD, or a plain JSON array of parts. The attachment files are named
+// like in the PartAttachment directory of PartKeepr.
+func ReadPartKeepr(r io.Reader) (*Inventory, error) {
+	var raw json.RawMessage
+	if err := decode(r, &raw); err != nil {
+		return nil, err
+	}
+
+	members := []partKeeprPart{}
+	if err := json.Unmarshal(raw, &members); err != nil {
+		collection := partKeeprCollection{}
+		if err := json.Unmarshal(raw, &collection); err != nil {
+			return nil, err
+		}
+		members = collection.Members
+	}
+
+	inv := new(Inventory)
+	for _, p := range members {
+		part := &Part{
+			Name:        p.Name,
+			Description: p.Description,
+			Stock:       float64(p.StockLevel),
+		}
+		if part.Description == "" {
+			part.Description = p.Comment
+		}
+
+		if p.Category != nil {
+			part.Category = splitPath(p.Category.CategoryPath, "➤")
+			if len(part.Category) == 0 && p.Category.Name != "" {
+				part.Category = []string{p.Category.Name}
+			}
+			if len(part.Category) > 1 && part.Category[0] == partKeeprRoot {
+				part.Category = part.Category[1:]
+			}
+		}
+		if p.StorageLocation != nil {
+			part.Place = p.StorageLocation.Name
+		}
+
+		for _, d := range p.PartDistributors {
+			if d.Distributor == nil {
+				inv.problem("part "+part.Name, "order number %s has no distributor", d.OrderNumber)
+				continue
+			}
+			part.Distributors = append(part.Distributors, Distributor{
+				Name:  d.Distributor.Name,
+				Key:   d.OrderNumber,
+				Price: float64(d.Price),
+			})
+		}
+
+		for _, a := range p.Attachments {
+			part.Attachments = append(part.Attachments, Attachment{
+				Name: a.OriginalFilename,
+				Type: a.Mimetype,
+				Path: a.Filename,
+			})
+		}
+
+		// Numeric parameters have a value with SI prefix and unit, the others
+		// a string value
+		for _, param := range p.PartParameters {
+			if !isValueParameter(param.Name) {
+				inv.problem("part "+part.Name, "parameter %s is not supported", param.Name)
+				continue
+			}
+			value := param.StringValue
+			if param.Value != nil {
+				value = param.Value.String()
+				if param.SiPrefix != nil {
+					value += param.SiPrefix.Symbol
+				}
+				if param.Unit != nil {
+					value += param.Unit.Symbol
+				}
+			}
+			if value != "" {
+				inv.setValue(part, value)
+			}
+		}
+
+		if len(p.Manufacturers) != 0 {
+			inv.problem("part "+part.Name, "manufacturers are not supported")
+		}
+		if len(p.Footprint) != 0 && string(p.Footprint) != "null" {
+			inv.problem("part "+part.Name, "footprints are not supported")
+		}
+
+		inv.Parts = append(inv.Parts, part)
+	}
+
+	return inv, nil
+}
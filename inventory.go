@@ -5,6 +5,8 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/fritz0705/inventory/labels"
+	"github.com/fritz0705/inventory/si"
 	"github.com/gorilla/sessions"
 	"github.com/jmoiron/sqlx"
 )
@@ -16,11 +18,15 @@ type Application struct {
 	DB              *sqlx.DB
 	Sessions        sessions.Store
 	AttachmentStore AttachmentStore
+	PrintQueue      *PrintQueue
 
 	// Configuration options
 	SessionName   string
 	TemplatesPath string
 	AssetsPath    string
+	NumberFormat  si.Format
+	// Printers are the label printers by their name
+	Printers map[string]*labels.Printer
 
 	*http.ServeMux
 
@@ -45,11 +51,15 @@ func (app *Application) Init() {
 
 	app.Templates = template.New("")
 	app.Templates.Funcs(templateFuncs)
+	app.Templates.Funcs(app.numberFuncs())
 	template.Must(app.Templates.ParseGlob(app.TemplatesPath + "/*.html"))
 
 	if app.Sessions == nil {
 		app.Sessions = sessions.NewCookieStore(SessionKey)
 	}
+	if app.PrintQueue == nil {
+		app.PrintQueue = NewPrintQueue(app.Printers)
+	}
 }
 
 func (app *Application) setUpRoutes() {
@@ -66,6 +76,8 @@ func (app *Application) setUpRoutes() {
 	app.HandleFunc("/settings", app.SettingsHandler)
 
 	app.HandleFunc("/search", app.SearchHandler)
+	app.HandleFunc("/search/suggest", app.SuggestHandler)
+	app.HandleFunc("/search/export", app.ExportSearchHandler)
 
 	app.HandleFunc("/parts", app.ListPartsHandler)
 	app.HandleFunc("/parts/", app.ShowPartHandler)
@@ -73,10 +85,15 @@ func (app *Application) setUpRoutes() {
 	app.HandleFunc("/parts/edit/", app.EditPartHandler)
 	app.HandleFunc("/parts/empty/", app.EmptyPartHandler)
 	app.HandleFunc("/parts/record/", app.CreatePartAmountHandler)
+	app.HandleFunc("/parts/calibrate/", app.CalibratePartHandler)
+	app.HandleFunc("/parts/packagings/new/", app.CreatePackagingHandler)
+	app.HandleFunc("/parts/packagings/delete/", app.DeletePackagingHandler)
 	app.HandleFunc("/parts/delete/", app.DeletePartHandler)
 	app.HandleFunc("/parts/upload/new/", app.PartUploadHandler)
 	app.HandleFunc("/parts/upload/delete/", app.PartUploadDeleteHandler)
 	app.HandleFunc("/parts/merge/", app.NewPartMergeHandler)
+	app.HandleFunc("/parts/bulk", app.BulkPartsHandler)
+	app.HandleFunc("/parts/export", app.ExportPartsHandler)
 
 	app.HandleFunc("/parts/distributors/new/", app.CreateDistributorPart)
 	app.HandleFunc("/parts/distributors/link/", app.DistributorPartRedirect)
@@ -92,6 +109,18 @@ func (app *Application) setUpRoutes() {
 	app.HandleFunc("/places/delete/", app.DeletePlaceHandler)
 	app.HandleFunc("/places/edit/", app.EditPlaceHandler)
 
+	app.HandleFunc("/views", app.ListViewsHandler)
+	app.HandleFunc("/views/", app.ShowViewHandler)
+	app.HandleFunc("/views/edit/", app.UpdateViewHandler)
+	app.HandleFunc("/views/delete/", app.DeleteViewHandler)
+
+	app.HandleFunc("/labels", app.LabelsHandler)
+	app.HandleFunc("/print", app.PrintHandler)
+
+	app.HandleFunc("/calculator", app.CalculatorHandler)
+	app.HandleFunc("/import", app.ImportHandler)
+	app.HandleFunc("/import/order", app.ImportOrderHandler)
+
 	app.HandleFunc("/attachments/", app.AttachmentsHandler)
 
 	app.Handle("/assets/", http.StripPrefix("/assets/", http.FileServer(http.Dir(app.AssetsPath))))
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"code.google.com/p/go-sqlite/go1/sqlite3"
+)
+
+const (
+	backupPrefix      = "inventory-"
+	backupTimeFormat  = "20060102-150405"
+	backupDatabase    = "inventory.db"
+	backupAttachments = "attachments"
+
+	// backupPages is the number of pages copied per step, the database is
+	// unlocked between the steps
+	backupPages = 256
+	// backupRetries is the number of retries of a step while the database is
+	// locked by the server
+	backupRetries = 50
+)
+
+// copyDatabase copies the SQLite database src to dst with the online backup
+// API, so src may be in use
+func copyDatabase(src, dst string) error {
+	srcConn, err := sqlite3.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	dstConn, err := sqlite3.Open(dst)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+
+	backup, err := srcConn.Backup("main", dstConn, "main")
+	if err != nil {
+		return err
+	}
+
+	retries := 0
+	for {
+		err = backup.Step(backupPages)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			// Steps fail while the server writes, they are retried
+			if retries++; retries > backupRetries {
+				backup.Close()
+				return err
+			}
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		retries = 0
+	}
+
+	return backup.Close()
+}
+
+// copyFiles copies the regular files of the directory src into dst. A missing
+// src is treated as empty directory.
+func copyFiles(src, dst string) error {
+	infos, err := ioutil.ReadDir(src)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dst, 0755)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		err := copyFile(filepath.Join(src, info.Name()), filepath.Join(dst, info.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// backup creates a backup of the database and the attachments as new directory
+// within dir and returns its path. The directory is renamed into place after
+// it is complete.
+func backup(config *Config, dir string) (string, error) {
+	name := backupPrefix + time.Now().Format(backupTimeFormat)
+	target := filepath.Join(dir, name)
+	tmp := filepath.Join(dir, "."+name)
+
+	err := os.MkdirAll(tmp, 0755)
+	if err != nil {
+		return "", err
+	}
+
+	err = copyDatabase(config.Database, filepath.Join(tmp, backupDatabase))
+	if err == nil && config.AttachmentsPath != "" {
+		err = copyFiles(config.AttachmentsPath, filepath.Join(tmp, backupAttachments))
+	}
+	if err == nil {
+		err = os.Rename(tmp, target)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	return target, nil
+}
+
+// restore replaces the database with the one of a backup and restores the
+// attachments of the backup. Attachments which are not part of the backup are
+// left untouched.
+func restore(config *Config, dir string) error {
+	src := filepath.Join(dir, backupDatabase)
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	err := copyDatabase(src, config.Database)
+	if err != nil {
+		return err
+	}
+
+	if config.AttachmentsPath == "" {
+		return nil
+	}
+	return copyFiles(filepath.Join(dir, backupAttachments), config.AttachmentsPath)
+}
+
+// backupEntry is a backup directory and its creation time
+type backupEntry struct {
+	Name string
+	Time time.Time
+}
+
+// listBackups returns the backups within dir, newest first
+func listBackups(dir string) ([]backupEntry, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	backups := []backupEntry{}
+	for _, info := range infos {
+		if !info.IsDir() || !strings.HasPrefix(info.Name(), backupPrefix) {
+			continue
+		}
+		t, err := time.ParseInLocation(backupTimeFormat,
+			info.Name()[len(backupPrefix):], time.Local)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backupEntry{info.Name(), t})
+	}
+
+	sort.Sort(newestFirst(backups))
+	return backups, nil
+}
+
+type newestFirst []backupEntry
+
+func (b newestFirst) Len() int           { return len(b) }
+func (b newestFirst) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b newestFirst) Less(i, j int) bool { return b[i].Time.After(b[j].Time) }
+
+// retainBackups returns the backups which are kept: the newest backup of each
+// of the newest daily days and of the newest weekly weeks
+func retainBackups(backups []backupEntry, daily, weekly int) map[string]bool {
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[[2]int]bool)
+
+	for _, b := range backups {
+		day := b.Time.Format("20060102")
+		if !days[day] && len(days) < daily {
+			keep[b.Name] = true
+		}
+		days[day] = true
+
+		year, week := b.Time.ISOWeek()
+		if !weeks[[2]int{year, week}] && len(weeks) < weekly {
+			keep[b.Name] = true
+		}
+		weeks[[2]int{year, week}] = true
+	}
+
+	return keep
+}
+
+// A backupScheduler creates a backup once a day and removes the backups which
+// are not retained
+type backupScheduler struct {
+	Config *Config
+	Dir    string
+	Daily  int
+	Weekly int
+}
+
+var errNoBackupPath = errors.New("no backup directory configured")
+
+// Run checks every hour whether a backup is due, it never returns
+func (s *backupScheduler) Run() {
+	for {
+		if err := s.run(time.Now()); err != nil {
+			log.Printf("backup: %v", err)
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+func (s *backupScheduler) run(now time.Time) error {
+	backups, err := listBackups(s.Dir)
+	if err != nil {
+		return err
+	}
+
+	y, m, d := now.Date()
+	if len(backups) == 0 || backups[0].Time.Before(time.Date(y, m, d, 0, 0, 0, 0, now.Location())) {
+		path, err := backup(s.Config, s.Dir)
+		if err != nil {
+			return err
+		}
+		log.Printf("backup: created %s", path)
+
+		backups, err = listBackups(s.Dir)
+		if err != nil {
+			return err
+		}
+	}
+
+	keep := retainBackups(backups, s.Daily, s.Weekly)
+	for _, b := range backups {
+		if keep[b.Name] {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(s.Dir, b.Name)); err != nil {
+			return err
+		}
+		log.Printf("backup: removed %s", b.Name)
+	}
+	return nil
+}
@@ -3,16 +3,21 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"net/http/fcgi"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	_ "code.google.com/p/go-sqlite/go1/sqlite3"
 	"github.com/fritz0705/inventory"
+	"github.com/fritz0705/inventory/importer"
+	"github.com/fritz0705/inventory/labels"
+	"github.com/fritz0705/inventory/si"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -23,9 +28,19 @@ type Config struct {
 	AssetsPath      string
 	AttachmentsPath string
 	SessionKey      []byte
+	NumberFormat    si.Format
+	// BackupPath is the directory of backups, BackupDaily and BackupWeekly
+	// enable the scheduled backups and set the number of retained backups
+	BackupPath   string
+	BackupDaily  int
+	BackupWeekly int
+	// Printers are the label printers by their name, e.g. {"zebra":
+	// {"Address": "192.168.1.20:9100", "Language": "zpl", "Width": 62,
+	// "Height": 29}}
+	Printers map[string]*labels.Printer
 }
 
-func handlerFactory(configFile string, basePath string) (http.Handler, error) {
+func loadConfig(configFile string, basePath string) (*Config, error) {
 	config := &Config{
 		Database: "inventory.db",
 		BasePath: basePath,
@@ -56,6 +71,12 @@ func handlerFactory(configFile string, basePath string) (http.Handler, error) {
 		}
 	}
 
+	return config, nil
+}
+
+// openApplication opens the database and attachments of the configuration,
+// the application is not initialized, so it does not serve requests
+func openApplication(config *Config) (*inventory.Application, error) {
 	if config.SessionKey != nil {
 		inventory.SessionKey = config.SessionKey
 	}
@@ -74,10 +95,157 @@ func handlerFactory(configFile string, basePath string) (http.Handler, error) {
 	if config.AttachmentsPath != "" {
 		handler.AttachmentStore = &inventory.FileAttachmentStore{config.AttachmentsPath}
 	}
+	handler.NumberFormat = config.NumberFormat
+	handler.Printers = config.Printers
+
+	return handler, err
+}
 
+// handlerFactory returns the initialized application, which parses the
+// templates and starts the workers of the printers
+func handlerFactory(config *Config) (*inventory.Application, error) {
+	handler, err := openApplication(config)
+	if err != nil {
+		return nil, err
+	}
 	handler.Init()
+	return handler, nil
+}
 
-	return handler, err
+// importCSV imports parts from a CSV file and prints what happened to every
+// row. Nothing is committed on a dry-run or if a row fails.
+func importCSV(app *inventory.Application, file string, opts *inventory.ImportOptions, dryRun bool) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	result, err := inventory.ImportCSV(tx, f, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range result.Rows {
+		details := strings.Join(row.Changes, ", ")
+		if row.Err != nil {
+			details = row.Err.Error()
+		}
+		fmt.Printf("%d\t%s\t%s\t%s\n", row.Line, row.Action, row.Name, details)
+	}
+	for _, name := range result.Categories {
+		fmt.Printf("new category %s\n", name)
+	}
+	for _, name := range result.Places {
+		fmt.Printf("new place %s\n", name)
+	}
+	fmt.Printf("%d created, %d updated, %d duplicates, %d errors\n",
+		result.Created, result.Updated, result.Duplicates, result.Errors)
+
+	switch {
+	case result.Errors != 0:
+		return fmt.Errorf("%d rows have errors, nothing was imported", result.Errors)
+	case dryRun:
+		fmt.Println("dry-run, nothing was imported")
+		return nil
+	}
+	return tx.Commit()
+}
+
+// importInventory imports the export of another inventory tool and prints
+// the created categories and places and the problems. Nothing is committed on
+// a dry-run.
+func importInventory(app *inventory.Application, file string, format string, files string, dryRun bool) error {
+	read, ok := importer.Formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q, known formats are %s", format,
+			strings.Join(importer.FormatNames(), ", "))
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	inv, err := read(f)
+	if err != nil {
+		return err
+	}
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	store := app.AttachmentStore
+	if dryRun {
+		store = nil
+	}
+	result, err := inventory.ImportInventory(tx, store, inv, files)
+	if err == nil && !dryRun {
+		err = tx.Commit()
+	}
+	if err != nil {
+		if result != nil {
+			for _, key := range result.Blobs {
+				store.Delete(key)
+			}
+		}
+		return err
+	}
+
+	for _, name := range result.Categories {
+		fmt.Printf("new category %s\n", name)
+	}
+	for _, name := range result.Places {
+		fmt.Printf("new place %s\n", name)
+	}
+	for _, name := range result.Matched {
+		fmt.Printf("updated part %s\n", name)
+	}
+	for _, problem := range result.Problems {
+		fmt.Printf("not converted: %s\n", problem)
+	}
+	fmt.Printf("%d parts, %d updated, %d attachments, %d problems\n", result.Parts,
+		len(result.Matched), result.Attachments, len(result.Problems))
+	if dryRun {
+		fmt.Println("dry-run, nothing was imported")
+	}
+	return nil
+}
+
+// exportArchive writes the whole inventory with its attachments into an
+// archive file
+func exportArchive(app *inventory.Application, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	err = inventory.WriteArchive(f, app.DB, app.AttachmentStore)
+	if err != nil {
+		f.Close()
+		os.Remove(file)
+		return err
+	}
+	return f.Close()
+}
+
+// importArchive imports an archive into the empty database
+func importArchive(app *inventory.Application, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	return inventory.ImportArchive(app.DB, app.AttachmentStore, f, info.Size())
 }
 
 func main() {
@@ -93,15 +261,108 @@ func main() {
 		flWriteTimeout   = flag.Duration("write-timeout", 10*time.Second, "write timeout")
 		flMaxHeaderBytes = flag.Int("buffer", 1<<20, "maximum header bytes")
 		flBase           = flag.String("base", "", "path to base directory")
+		flImportColumns  = flag.String("import-columns", "", "column mapping of the import, e.g. name=Part,value=3")
+		flImportFrom     = flag.String("import-from", "", "import the export of another tool instead of CSV (inventree, partdb or partkeepr)")
+		flImportFiles    = flag.String("import-files", ".", "directory of the attachment files of the imported export")
+		flCreate         = flag.Bool("import-create", false, "create unknown categories and places while importing")
+		flDryRun         = flag.Bool("dry-run", false, "show the changes of the import without committing them")
 	)
 
 	flag.Parse()
 
-	handler, err := handlerFactory(*flConfig, *flBase)
+	config, err := loadConfig(*flConfig, *flBase)
+	if err != nil {
+		log.Fatalf("An error occured while loading the configuration: %s", err)
+	}
+
+	switch flag.Arg(0) {
+	case "backup":
+		dir := flag.Arg(1)
+		if dir == "" {
+			dir = config.BackupPath
+		}
+		if dir == "" {
+			log.Fatal(errNoBackupPath)
+		}
+		path, err := backup(config, dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(path)
+		return
+	case "restore":
+		if flag.Arg(1) == "" {
+			log.Fatal("restore requires the backup directory")
+		}
+		if err := restore(config, flag.Arg(1)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "import":
+		if flag.Arg(1) == "" {
+			log.Fatal("import requires the file")
+		}
+		handler, err := openApplication(config)
+		if err != nil {
+			log.Fatalf("An error occured while opening the database: %s", err)
+		}
+		if *flImportFrom != "" {
+			err = importInventory(handler, flag.Arg(1), *flImportFrom, *flImportFiles, *flDryRun)
+		} else {
+			var columns map[string]string
+			columns, err = inventory.ParseImportColumns(*flImportColumns)
+			if err == nil {
+				err = importCSV(handler, flag.Arg(1), &inventory.ImportOptions{
+					Columns:          columns,
+					CreateCategories: *flCreate,
+					CreatePlaces:     *flCreate,
+				}, *flDryRun)
+			}
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "export-archive", "import-archive":
+		if flag.Arg(1) == "" {
+			log.Fatalf("%s requires the archive file", flag.Arg(0))
+		}
+		handler, err := openApplication(config)
+		if err != nil {
+			log.Fatalf("An error occured while opening the database: %s", err)
+		}
+		if flag.Arg(0) == "export-archive" {
+			err = exportArchive(handler, flag.Arg(1))
+		} else {
+			err = importArchive(handler, flag.Arg(1))
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "":
+	default:
+		log.Fatalf("Invalid command: %v (Valid commands are 'backup', 'restore', 'import', 'export-archive' and 'import-archive')", flag.Arg(0))
+	}
+
+	handler, err := handlerFactory(config)
 	if err != nil {
 		log.Fatalf("An error occured while initializing the application: %s", err)
 	}
 
+	if config.BackupDaily > 0 || config.BackupWeekly > 0 {
+		if config.BackupPath == "" {
+			log.Fatal(errNoBackupPath)
+		}
+		scheduler := &backupScheduler{
+			Config: config,
+			Dir:    config.BackupPath,
+			Daily:  config.BackupDaily,
+			Weekly: config.BackupWeekly,
+		}
+		go scheduler.Run()
+	}
+
 	switch *flMode {
 	case "http":
 		s := &http.Server{
@@ -0,0 +1,138 @@
+package inventory
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fritz0705/inventory/labels"
+	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
+)
+
+// baseURL returns the scheme and host of the request, it is the prefix of the
+// URLs encoded into labels
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// partLabel returns the label of a part with its value, category and place.
+// The value is shown in the canonical form, like in searches.
+func (app *Application) partLabel(part *PartView, base string) labels.Label {
+	label := labels.Label{
+		Title: part.Name,
+		URL:   fmt.Sprintf("%s/parts/%d", base, part.Id),
+	}
+	if part.Value.Valid {
+		label.Lines = append(label.Lines,
+			si.New(part.Value.Float64).Format(app.NumberFormat)+part.UnitSymbol.String)
+	}
+	label.Lines = append(label.Lines, part.CategoryName)
+	if part.PlaceName.Valid {
+		label.Lines = append(label.Lines, part.PlaceName.String)
+	}
+	return label
+}
+
+// placeLabel returns the label of a place with its number of parts
+func placeLabel(place *Place, parts int, base string) labels.Label {
+	return labels.Label{
+		Title: place.Name,
+		Lines: []string{fmt.Sprintf("%d parts", parts)},
+		URL:   fmt.Sprintf("%s/places/edit/%d", base, place.Id),
+	}
+}
+
+// collectLabels returns the labels of the parts, the places and the parts
+// stored at the places given by their ids. Unknown ids are skipped.
+func (app *Application) collectLabels(q sqlx.Queryer, parts, places, placeParts []string, base string) ([]labels.Label, error) {
+	res := []labels.Label{}
+
+	for _, id := range parts {
+		part := new(PartView)
+		err := sqlx.Get(q, part, `SELECT * FROM 'part_view' WHERE "id" = ?`, id)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		res = append(res, app.partLabel(part, base))
+	}
+
+	for _, id := range places {
+		place := new(Place)
+		err := sqlx.Get(q, place, `SELECT * FROM 'place' WHERE "id" = ?`, id)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var count int
+		err = sqlx.Get(q, &count, `SELECT COUNT(*) FROM 'part' WHERE "place_id" = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, placeLabel(place, count, base))
+	}
+
+	for _, id := range placeParts {
+		partViews := []PartView{}
+		err := sqlx.Select(q, &partViews, `SELECT * FROM 'part_view'
+		WHERE "place_id" = ? ORDER BY "name" ASC`, id)
+		if err != nil {
+			return nil, err
+		}
+		for n := range partViews {
+			res = append(res, app.partLabel(&partViews[n], base))
+		}
+	}
+
+	return res, nil
+}
+
+// LabelsHandler renders a PDF sheet of labels with QR codes. The parts and
+// places are given by their ids in the parameters parts, places and
+// place_parts, the latter selects all parts of a place.
+func (app *Application) LabelsHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	layoutName := r.Form.Get("layout")
+	if layoutName == "" {
+		layoutName = labels.DefaultLayout
+	}
+	layout, ok := labels.Layouts[layoutName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown label layout %q", layoutName), http.StatusBadRequest)
+		return
+	}
+	skip, _ := strconv.Atoi(r.Form.Get("skip"))
+	if skip < 0 {
+		skip = 0
+	}
+
+	res, err := app.collectLabels(app.DB, r.Form["parts"], r.Form["places"],
+		r.Form["place_parts"], baseURL(r))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	if len(res) == 0 {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", `inline; filename="labels.pdf"`)
+	if err := labels.WritePDF(w, layout, res, skip); err != nil {
+		app.Error(w, err)
+	}
+}
@@ -0,0 +1,171 @@
+package labels
+
+// A Bitmap is a monochrome image of a label in printer dots
+type Bitmap struct {
+	Width  int
+	Height int
+	dots   []bool
+}
+
+// NewBitmap returns a white bitmap
+func NewBitmap(width, height int) *Bitmap {
+	return &Bitmap{width, height, make([]bool, width*height)}
+}
+
+// Dark reports whether the dot is black, dots outside of the bitmap are white
+func (b *Bitmap) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.Width || y >= b.Height {
+		return false
+	}
+	return b.dots[y*b.Width+x]
+}
+
+// Rect fills a rectangle, it is clipped to the bitmap
+func (b *Bitmap) Rect(x, y, width, height int) {
+	for row := y; row < y+height; row++ {
+		for col := x; col < x+width; col++ {
+			if col >= 0 && row >= 0 && col < b.Width && row < b.Height {
+				b.dots[row*b.Width+col] = true
+			}
+		}
+	}
+}
+
+// Row returns a row packed into bytes, the most significant bit first
+func (b *Bitmap) Row(y int) []byte {
+	row := make([]byte, (b.Width+7)/8)
+	for x := 0; x < b.Width; x++ {
+		if b.Dark(x, y) {
+			row[x/8] |= 0x80 >> uint(x%8)
+		}
+	}
+	return row
+}
+
+// DrawQR draws the symbol with its quiet zone, the upper left corner of the
+// quiet zone is at x and y
+func (b *Bitmap) DrawQR(qr *QR, x, y, module int) {
+	for row := 0; row < qr.Size; row++ {
+		for col := 0; col < qr.Size; col++ {
+			if qr.Dark(col, row) {
+				b.Rect(x+(col+quietZone)*module, y+(row+quietZone)*module, module, module)
+			}
+		}
+	}
+}
+
+// Glyphs of the bitmap font are 5 dots wide and 7 dots high, they are
+// followed by one dot of space
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphAdvance = glyphWidth + 1
+)
+
+// font5x7 are the glyphs of the printable ASCII characters by columns, the
+// least significant bit is the top row
+var font5x7 = [95][glyphWidth]byte{
+	{0x00, 0x00, 0x00, 0x00, 0x00}, {0x00, 0x00, 0x5F, 0x00, 0x00},
+	{0x00, 0x07, 0x00, 0x07, 0x00}, {0x14, 0x7F, 0x14, 0x7F, 0x14},
+	{0x24, 0x2A, 0x7F, 0x2A, 0x12}, {0x23, 0x13, 0x08, 0x64, 0x62},
+	{0x36, 0x49, 0x55, 0x22, 0x50}, {0x00, 0x05, 0x03, 0x00, 0x00},
+	{0x00, 0x1C, 0x22, 0x41, 0x00}, {0x00, 0x41, 0x22, 0x1C, 0x00},
+	{0x14, 0x08, 0x3E, 0x08, 0x14}, {0x08, 0x08, 0x3E, 0x08, 0x08},
+	{0x00, 0x50, 0x30, 0x00, 0x00}, {0x08, 0x08, 0x08, 0x08, 0x08},
+	{0x00, 0x60, 0x60, 0x00, 0x00}, {0x20, 0x10, 0x08, 0x04, 0x02},
+	{0x3E, 0x51, 0x49, 0x45, 0x3E}, {0x00, 0x42, 0x7F, 0x40, 0x00},
+	{0x42, 0x61, 0x51, 0x49, 0x46}, {0x21, 0x41, 0x45, 0x4B, 0x31},
+	{0x18, 0x14, 0x12, 0x7F, 0x10}, {0x27, 0x45, 0x45, 0x45, 0x39},
+	{0x3C, 0x4A, 0x49, 0x49, 0x30}, {0x01, 0x71, 0x09, 0x05, 0x03},
+	{0x36, 0x49, 0x49, 0x49, 0x36}, {0x06, 0x49, 0x49, 0x29, 0x1E},
+	{0x00, 0x36, 0x36, 0x00, 0x00}, {0x00, 0x56, 0x36, 0x00, 0x00},
+	{0x08, 0x14, 0x22, 0x41, 0x00}, {0x14, 0x14, 0x14, 0x14, 0x14},
+	{0x00, 0x41, 0x22, 0x14, 0x08}, {0x02, 0x01, 0x51, 0x09, 0x06},
+	{0x32, 0x49, 0x79, 0x41, 0x3E}, {0x7E, 0x11, 0x11, 0x11, 0x7E},
+	{0x7F, 0x49, 0x49, 0x49, 0x36}, {0x3E, 0x41, 0x41, 0x41, 0x22},
+	{0x7F, 0x41, 0x41, 0x22, 0x1C}, {0x7F, 0x49, 0x49, 0x49, 0x41},
+	{0x7F, 0x09, 0x09, 0x09, 0x01}, {0x3E, 0x41, 0x49, 0x49, 0x7A},
+	{0x7F, 0x08, 0x08, 0x08, 0x7F}, {0x00, 0x41, 0x7F, 0x41, 0x00},
+	{0x20, 0x40, 0x41, 0x3F, 0x01}, {0x7F, 0x08, 0x14, 0x22, 0x41},
+	{0x7F, 0x40, 0x40, 0x40, 0x40}, {0x7F, 0x02, 0x0C, 0x02, 0x7F},
+	{0x7F, 0x04, 0x08, 0x10, 0x7F}, {0x3E, 0x41, 0x41, 0x41, 0x3E},
+	{0x7F, 0x09, 0x09, 0x09, 0x06}, {0x3E, 0x41, 0x51, 0x21, 0x5E},
+	{0x7F, 0x09, 0x19, 0x29, 0x46}, {0x46, 0x49, 0x49, 0x49, 0x31},
+	{0x01, 0x01, 0x7F, 0x01, 0x01}, {0x3F, 0x40, 0x40, 0x40, 0x3F},
+	{0x1F, 0x20, 0x40, 0x20, 0x1F}, {0x3F, 0x40, 0x38, 0x40, 0x3F},
+	{0x63, 0x14, 0x08, 0x14, 0x63}, {0x07, 0x08, 0x70, 0x08, 0x07},
+	{0x61, 0x51, 0x49, 0x45, 0x43}, {0x00, 0x7F, 0x41, 0x41, 0x00},
+	{0x02, 0x04, 0x08, 0x10, 0x20}, {0x00, 0x41, 0x41, 0x7F, 0x00},
+	{0x04, 0x02, 0x01, 0x02, 0x04}, {0x40, 0x40, 0x40, 0x40, 0x40},
+	{0x00, 0x01, 0x02, 0x04, 0x00}, {0x20, 0x54, 0x54, 0x54, 0x78},
+	{0x7F, 0x48, 0x44, 0x44, 0x38}, {0x38, 0x44, 0x44, 0x44, 0x20},
+	{0x38, 0x44, 0x44, 0x48, 0x7F}, {0x38, 0x54, 0x54, 0x54, 0x18},
+	{0x08, 0x7E, 0x09, 0x01, 0x02}, {0x0C, 0x52, 0x52, 0x52, 0x3E},
+	{0x7F, 0x08, 0x04, 0x04, 0x78}, {0x00, 0x44, 0x7D, 0x40, 0x00},
+	{0x20, 0x40, 0x44, 0x3D, 0x00}, {0x7F, 0x10, 0x28, 0x44, 0x00},
+	{0x00, 0x41, 0x7F, 0x40, 0x00}, {0x7C, 0x04, 0x18, 0x04, 0x78},
+	{0x7C, 0x08, 0x04, 0x04, 0x78}, {0x38, 0x44, 0x44, 0x44, 0x38},
+	{0x7C, 0x14, 0x14, 0x14, 0x08}, {0x08, 0x14, 0x14, 0x18, 0x7C},
+	{0x7C, 0x08, 0x04, 0x04, 0x08}, {0x48, 0x54, 0x54, 0x54, 0x20},
+	{0x04, 0x3F, 0x44, 0x40, 0x20}, {0x3C, 0x40, 0x40, 0x20, 0x7C},
+	{0x1C, 0x20, 0x40, 0x20, 0x1C}, {0x3C, 0x40, 0x30, 0x40, 0x3C},
+	{0x44, 0x28, 0x10, 0x28, 0x44}, {0x0C, 0x50, 0x50, 0x50, 0x3C},
+	{0x44, 0x64, 0x54, 0x4C, 0x44}, {0x00, 0x08, 0x36, 0x41, 0x00},
+	{0x00, 0x00, 0x7F, 0x00, 0x00}, {0x00, 0x41, 0x36, 0x08, 0x00},
+	{0x02, 0x01, 0x02, 0x04, 0x02},
+}
+
+// glyph returns the glyph of a WinAnsi character, micro signs are drawn as u
+// and unknown characters as question marks
+func glyph(c byte) [glyphWidth]byte {
+	switch {
+	case c >= 32 && c < 127:
+		return font5x7[c-32]
+	case c == 0x85:
+		return [glyphWidth]byte{0x40, 0x00, 0x40, 0x00, 0x40}
+	case c == 0xB5:
+		return font5x7['u'-32]
+	}
+	return font5x7['?'-32]
+}
+
+// bitmapTextWidth returns the width of text drawn with the scale
+func bitmapTextWidth(scale int, text string) int {
+	n := len(winAnsi(text))
+	if n == 0 {
+		return 0
+	}
+	return (n*glyphAdvance - 1) * scale
+}
+
+// Text draws text with its upper left corner at x and y, every dot of the
+// font is drawn as a square of scale dots
+func (b *Bitmap) Text(x, y, scale int, text string) {
+	for _, c := range []byte(winAnsi(text)) {
+		g := glyph(c)
+		for col, bits := range g {
+			for row := 0; row < glyphHeight; row++ {
+				if bits&(1<<uint(row)) != 0 {
+					b.Rect(x+col*scale, y+row*scale, scale, scale)
+				}
+			}
+		}
+		x += glyphAdvance * scale
+	}
+}
+
+// fitBitmap shortens text with an ellipsis until it fits into the width
+// when drawn with the scale
+func fitBitmap(scale, width int, text string) string {
+	if bitmapTextWidth(scale, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if s := string(runes) + "…"; bitmapTextWidth(scale, s) <= width {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,51 @@
+package labels
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBitmapText(t *testing.T) {
+	b := NewBitmap(16, 8)
+	b.Text(0, 0, 1, "H")
+	rows := [][]byte{
+		{0x88, 0x00}, {0x88, 0x00}, {0x88, 0x00}, {0xF8, 0x00},
+		{0x88, 0x00}, {0x88, 0x00}, {0x88, 0x00}, {0x00, 0x00},
+	}
+	for y, row := range rows {
+		if res := b.Row(y); !bytes.Equal(res, row) {
+			t.Errorf("Got row %d %x, wants %x", y, res, row)
+		}
+	}
+
+	b = NewBitmap(20, 20)
+	b.Text(2, 2, 2, "l")
+	if !b.Dark(6, 2) || !b.Dark(7, 15) || b.Dark(6, 16) || b.Dark(1, 2) {
+		t.Error("Scaled glyph is misplaced")
+	}
+}
+
+func TestFitBitmap(t *testing.T) {
+	type testCase struct {
+		Scale  int
+		Width  int
+		Text   string
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{1, 100, "Resistor", "Resistor"},
+		testCase{1, 47, "Resistor", "Resistor"},
+		testCase{1, 46, "Resistor", "Resist…"},
+		testCase{2, 46, "Resistor", "Res…"},
+		testCase{1, 4, "Resistor", ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := fitBitmap(testCase.Scale, testCase.Width, testCase.Text); res != testCase.Result {
+			t.Errorf("Got %q, wants %q", res, testCase.Result)
+		}
+	}
+}
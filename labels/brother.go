@@ -0,0 +1,169 @@
+package labels
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+)
+
+// BrotherDPI is the resolution of the Brother QL printers
+const BrotherDPI = 300
+
+// brotherLineBytes is the length of a raster line, the print head has 720
+// dots
+const brotherLineBytes = 90
+
+// A BrotherMedia is a tape of a Brother QL printer, Width and Length are in
+// millimetres. Die-cut labels have a length, continuous tapes have none.
+// Printable and Lines are the printable area in dots, Margin is the number of
+// unused dots on the right side of the print head.
+type BrotherMedia struct {
+	Width     float64
+	Length    float64
+	Printable int
+	Lines     int
+	Margin    int
+}
+
+// BrotherMedias are the known tapes by their name
+var BrotherMedias = map[string]*BrotherMedia{
+	"12":     {12, 0, 106, 0, 29},
+	"29":     {29, 0, 306, 0, 6},
+	"38":     {38, 0, 413, 0, 12},
+	"50":     {50, 0, 554, 0, 12},
+	"54":     {54, 0, 590, 0, 0},
+	"62":     {62, 0, 696, 0, 12},
+	"17x54":  {17, 54, 165, 566, 0},
+	"29x90":  {29, 90, 306, 991, 6},
+	"38x90":  {38, 90, 413, 991, 12},
+	"62x29":  {62, 29, 696, 271, 12},
+	"62x100": {62, 100, 696, 1109, 12},
+}
+
+// BrotherMediaNames returns the names of BrotherMedias in order
+func BrotherMediaNames() []string {
+	names := make([]string, 0, len(BrotherMedias))
+	for name := range BrotherMedias {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrUnknownMedia is returned for tapes which are not in BrotherMedias
+var ErrUnknownMedia = errors.New("labels: unknown Brother media")
+
+// RenderBitmap draws a label into a bitmap of width × height dots with the
+// bitmap font
+func RenderBitmap(label Label, width, height, dpi int) (*Bitmap, error) {
+	b := NewBitmap(width, height)
+	pad := int(2 * float64(dpi) / 25.4)
+
+	textX := pad
+	if label.URL != "" {
+		qr, err := EncodeQR([]byte(label.URL))
+		if err != nil {
+			return nil, err
+		}
+		side := minInt(height-2*pad, width/2)
+		module := side / (qr.Size + 2*quietZone)
+		if module < 1 {
+			module = 1
+		}
+		b.DrawQR(qr, pad, pad, module)
+		textX += side + pad
+	}
+	textWidth := width - pad - textX
+
+	// The glyphs are scaled to the sizes of the PDF labels
+	heightMM := float64(height) * 25.4 / float64(dpi)
+	scale := math.Min(1, heightMM/38+0.25)
+	title := max(1, int(titleSize*scale*float64(dpi)/72/(glyphHeight+1)))
+	size := max(1, int(lineSize*scale*float64(dpi)/72/(glyphHeight+1)))
+
+	y := pad
+	b.Text(textX, y, title, fitBitmap(title, textWidth, label.Title))
+	y += (glyphHeight + 3) * title
+	for _, line := range label.Lines {
+		if y+glyphHeight*size > height-pad {
+			break
+		}
+		b.Text(textX, y, size, fitBitmap(size, textWidth, line))
+		y += (glyphHeight + 2) * size
+	}
+	return b, nil
+}
+
+// WriteBrotherQL renders every label as a page of the raster command
+// language of the Brother QL printers. Labels on continuous tapes are length
+// millimetres long, die-cut labels have the length of the media.
+func WriteBrotherQL(buf *bytes.Buffer, labels []Label, media *BrotherMedia, length float64) error {
+	lines := media.Lines
+	if lines == 0 {
+		lines = int(length * BrotherDPI / 25.4)
+	}
+	if lines <= 0 {
+		return ErrInvalidLayout
+	}
+
+	// Invalidate, initialize and switch to raster mode
+	buf.Write(make([]byte, 200))
+	buf.Write([]byte{0x1B, 0x40})
+	buf.Write([]byte{0x1B, 0x69, 0x61, 0x01})
+
+	for n, label := range labels {
+		b, err := RenderBitmap(label, media.Printable, lines, BrotherDPI)
+		if err != nil {
+			return err
+		}
+
+		// Print information: the media type, width and length are valid
+		info := []byte{0x1B, 0x69, 0x7A, 0x86, 0x0A, byte(media.Width), 0, 0, 0, 0, 0, 1, 0}
+		if media.Length > 0 {
+			info[3], info[4], info[6] = 0x8E, 0x0B, byte(media.Length)
+		}
+		binary.LittleEndian.PutUint32(info[7:11], uint32(lines))
+		if n == 0 {
+			info[11] = 0
+		}
+		buf.Write(info)
+
+		// Cut after every label and at the end, continuous tapes are fed by
+		// a margin of 35 dots
+		buf.Write([]byte{0x1B, 0x69, 0x4D, 0x40})
+		buf.Write([]byte{0x1B, 0x69, 0x41, 0x01})
+		buf.Write([]byte{0x1B, 0x69, 0x4B, 0x08})
+		margin := 0
+		if media.Length == 0 {
+			margin = 35
+		}
+		buf.Write([]byte{0x1B, 0x69, 0x64, byte(margin), byte(margin >> 8)})
+		buf.Write([]byte{0x4D, 0x00})
+
+		for y := 0; y < lines; y++ {
+			buf.Write([]byte{0x67, 0x00, brotherLineBytes})
+			buf.Write(brotherLine(b, y, media))
+		}
+
+		if n == len(labels)-1 {
+			buf.WriteByte(0x1A)
+		} else {
+			buf.WriteByte(0x0C)
+		}
+	}
+	return nil
+}
+
+// brotherLine returns a raster line of the bitmap. The print head prints the
+// first dot of a line on the right side, so the line is mirrored.
+func brotherLine(b *Bitmap, y int, media *BrotherMedia) []byte {
+	line := make([]byte, brotherLineBytes)
+	for p := media.Margin; p < media.Margin+media.Printable; p++ {
+		if b.Dark(media.Printable+media.Margin-1-p, y) {
+			line[p/8] |= 0x80 >> uint(p%8)
+		}
+	}
+	return line
+}
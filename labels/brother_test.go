@@ -0,0 +1,106 @@
+package labels
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteBrotherQL(t *testing.T) {
+	type testCase struct {
+		Media  string
+		Length float64
+		Lines  int
+		Info   []byte
+	}
+
+	testCases := []testCase{
+		testCase{"62", 29, 342, []byte{0x1B, 0x69, 0x7A, 0x86, 0x0A, 62, 0, 0x56, 0x01, 0, 0, 0, 0}},
+		testCase{"62x29", 0, 271, []byte{0x1B, 0x69, 0x7A, 0x8E, 0x0B, 62, 29, 0x0F, 0x01, 0, 0, 0, 0}},
+		testCase{"29x90", 0, 991, []byte{0x1B, 0x69, 0x7A, 0x8E, 0x0B, 29, 90, 0xDF, 0x03, 0, 0, 0, 0}},
+	}
+
+	labels := []Label{
+		Label{Title: "Resistor", Lines: []string{"4.7 kΩ"}, URL: "http://localhost:8901/parts/1"},
+		Label{Title: "Shelf", URL: "http://localhost:8901/places/edit/1"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		var buf bytes.Buffer
+		err := WriteBrotherQL(&buf, labels, BrotherMedias[testCase.Media], testCase.Length)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		data := buf.Bytes()
+
+		header := append(make([]byte, 200), 0x1B, 0x40, 0x1B, 0x69, 0x61, 0x01)
+		if !bytes.HasPrefix(data, header) {
+			t.Error("Invalid header")
+			continue
+		}
+		data = data[len(header):]
+
+		for page := 0; page < len(labels); page++ {
+			info := append([]byte{}, testCase.Info...)
+			if page > 0 {
+				info[11] = 1
+			}
+			if !bytes.HasPrefix(data, info) {
+				t.Errorf("Got print information %x, wants %x", data[:13], info)
+				break
+			}
+
+			// The commands of the page are followed by the raster lines
+			data = data[13+4+4+4+5+2:]
+			dark := 0
+			for n := 0; n < testCase.Lines; n++ {
+				if !bytes.HasPrefix(data, []byte{0x67, 0x00, brotherLineBytes}) {
+					t.Fatalf("Raster line %d is invalid", n)
+				}
+				for _, b := range data[3 : 3+brotherLineBytes] {
+					for ; b != 0; b &= b - 1 {
+						dark++
+					}
+				}
+				data = data[3+brotherLineBytes:]
+			}
+			if dark == 0 {
+				t.Errorf("Page %d is empty", page)
+			}
+
+			end := byte(0x0C)
+			if page == len(labels)-1 {
+				end = 0x1A
+			}
+			if data[0] != end {
+				t.Errorf("Got page end %#x, wants %#x", data[0], end)
+			}
+			data = data[1:]
+		}
+		if len(data) != 0 {
+			t.Errorf("Got %d trailing bytes", len(data))
+		}
+	}
+
+	if err := WriteBrotherQL(new(bytes.Buffer), labels, BrotherMedias["62"], 0); err != ErrInvalidLayout {
+		t.Errorf("Got %v, wants %v", err, ErrInvalidLayout)
+	}
+}
+
+func TestBrotherLine(t *testing.T) {
+	media := BrotherMedias["29"]
+	b := NewBitmap(media.Printable, 1)
+	b.Rect(0, 0, 1, 1)
+	b.Rect(media.Printable-1, 0, 1, 1)
+
+	// The first dot is printed on the left, so it is the last one of the line
+	line := brotherLine(b, 0, media)
+	want := make([]byte, brotherLineBytes)
+	want[0] = 0x02
+	want[(media.Margin+media.Printable-1)/8] = 0x80 >> uint((media.Margin+media.Printable-1)%8)
+	if !bytes.Equal(line, want) {
+		t.Errorf("Got %x, wants %x", line, want)
+	}
+}
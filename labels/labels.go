@@ -0,0 +1,172 @@
+// Package labels renders label sheets with QR codes as PDF documents and
+// labels for ZPL and Brother QL label printers. The QR codes, the documents
+// and the print data are generated without external dependencies.
+package labels
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sort"
+)
+
+// pointsPerMM converts millimetres into PDF points
+const pointsPerMM = 72 / 25.4
+
+// A Layout describes a sheet of labels, all lengths are in millimetres. The
+// labels are placed in rows from the top left corner.
+type Layout struct {
+	Description string
+	PageWidth   float64
+	PageHeight  float64
+	Columns     int
+	Rows        int
+	LabelWidth  float64
+	LabelHeight float64
+	// Left and Top are the margins of the first label, PitchX and PitchY the
+	// distances between the origins of neighbouring labels
+	Left   float64
+	Top    float64
+	PitchX float64
+	PitchY float64
+}
+
+// Layouts are the known label sheets by name
+var Layouts = map[string]*Layout{
+	"avery-l7160":  {"Avery L7160, 21 labels 63.5 × 38.1 mm", 210, 297, 3, 7, 63.5, 38.1, 7.2, 15.1, 66, 38.1},
+	"avery-l7163":  {"Avery L7163, 14 labels 99.1 × 38.1 mm", 210, 297, 2, 7, 99.1, 38.1, 4.65, 15.15, 101.6, 38.1},
+	"avery-l7651":  {"Avery L7651, 65 labels 38.1 × 21.2 mm", 210, 297, 5, 13, 38.1, 21.2, 4.75, 10.7, 40.6, 21.2},
+	"avery-3474":   {"Avery 3474, 24 labels 70 × 37 mm", 210, 297, 3, 8, 70, 37, 0, 0.5, 70, 37},
+	"single-62x29": {"Single labels 62 × 29 mm", 62, 29, 1, 1, 62, 29, 0, 0, 62, 29},
+}
+
+// DefaultLayout is the name of the layout used if none is given
+const DefaultLayout = "avery-l7160"
+
+// LayoutNames returns the names of Layouts in order
+func LayoutNames() []string {
+	names := make([]string, 0, len(Layouts))
+	for name := range Layouts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrInvalidLayout is returned for layouts without labels
+var ErrInvalidLayout = errors.New("labels: invalid layout")
+
+// A Label has a title in bold, some lines of text and a QR code of the URL
+type Label struct {
+	Title string
+	Lines []string
+	URL   string
+}
+
+// label dimensions in points
+const (
+	padding   = 2 * pointsPerMM
+	quietZone = 2
+	titleSize = 10.0
+	lineSize  = 7.0
+)
+
+// WritePDF writes the labels onto as many sheets as needed. Skip is the
+// number of labels which are left empty on the first sheet, so partially
+// used sheets can be printed again.
+func WritePDF(w io.Writer, layout *Layout, labels []Label, skip int) error {
+	perPage := layout.Columns * layout.Rows
+	if perPage <= 0 {
+		return ErrInvalidLayout
+	}
+
+	pdf := NewPDF(layout.PageWidth*pointsPerMM, layout.PageHeight*pointsPerMM)
+	for n, label := range labels {
+		pos := (n + skip) % perPage
+		if n == 0 || pos == 0 {
+			pdf.AddPage()
+		}
+
+		column, row := pos%layout.Columns, pos/layout.Columns
+		x := (layout.Left + float64(column)*layout.PitchX) * pointsPerMM
+		top := pdf.Height - (layout.Top+float64(row)*layout.PitchY)*pointsPerMM
+		if err := drawLabel(pdf, layout, label, x, top); err != nil {
+			return err
+		}
+	}
+
+	_, err := pdf.WriteTo(w)
+	return err
+}
+
+// drawLabel draws a label, x and top are the coordinates of its upper left
+// corner
+func drawLabel(pdf *PDF, layout *Layout, label Label, x, top float64) error {
+	width := layout.LabelWidth * pointsPerMM
+	height := layout.LabelHeight * pointsPerMM
+
+	// The QR code is a square on the left side, at most half of the label
+	textX := x + padding
+	if label.URL != "" {
+		side := math.Min(height-2*padding, width/2)
+		qr, err := EncodeQR([]byte(label.URL))
+		if err != nil {
+			return err
+		}
+		drawQR(pdf, qr, x+padding, top-padding-side, side)
+		textX += side + padding
+	}
+	textWidth := x + width - padding - textX
+
+	// Small labels get smaller text
+	scale := math.Min(1, height/(38*pointsPerMM)+0.25)
+	title, size := titleSize*scale, lineSize*scale
+
+	y := top - padding - title
+	pdf.Text(textX, y, Bold, title, fit(Bold, title, textWidth, label.Title))
+	y -= title * 0.4
+	for _, line := range label.Lines {
+		y -= size * 1.2
+		if y < top-height+padding {
+			break
+		}
+		pdf.Text(textX, y, Regular, size, fit(Regular, size, textWidth, line))
+	}
+	return nil
+}
+
+// drawQR draws the symbol with its quiet zone into a square, adjacent dark
+// modules of a row are drawn as one rectangle
+func drawQR(pdf *PDF, qr *QR, x, y, side float64) {
+	module := side / float64(qr.Size+2*quietZone)
+	for row := 0; row < qr.Size; row++ {
+		for col := 0; col < qr.Size; {
+			if !qr.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < qr.Size && qr.Dark(col, row) {
+				col++
+			}
+			pdf.Rect(x+float64(start+quietZone)*module,
+				y+side-float64(row+quietZone+1)*module,
+				float64(col-start)*module, module)
+		}
+	}
+}
+
+// fit shortens text with an ellipsis until it fits into the width
+func fit(font string, size, width float64, text string) string {
+	if TextWidth(font, size, text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if s := string(runes) + "…"; TextWidth(font, size, s) <= width {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package labels
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWritePDF(t *testing.T) {
+	type testCase struct {
+		Layout string
+		Labels int
+		Skip   int
+		Pages  int
+	}
+
+	testCases := []testCase{
+		testCase{"avery-l7160", 1, 0, 1},
+		testCase{"avery-l7160", 21, 0, 1},
+		testCase{"avery-l7160", 21, 1, 2},
+		testCase{"avery-l7651", 130, 0, 2},
+		testCase{"single-62x29", 3, 0, 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		labels := make([]Label, testCase.Labels)
+		for n := range labels {
+			labels[n] = Label{
+				Title: "Metal film resistor with a long name",
+				Lines: []string{"4.7 kΩ", "Resistors", "Drawer 12"},
+				URL:   "http://localhost:8901/parts/1",
+			}
+		}
+
+		var buf bytes.Buffer
+		err := WritePDF(&buf, Layouts[testCase.Layout], labels, testCase.Skip)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if pages := strings.Count(buf.String(), "/Type /Page "); pages != testCase.Pages {
+			t.Errorf("Got %d pages, wants %d", pages, testCase.Pages)
+		}
+	}
+
+	if err := WritePDF(new(bytes.Buffer), &Layout{}, nil, 0); err != ErrInvalidLayout {
+		t.Errorf("Got %v, wants %v", err, ErrInvalidLayout)
+	}
+}
+
+func TestFit(t *testing.T) {
+	type testCase struct {
+		Text   string
+		Width  float64
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{"Resistor", 100, "Resistor"},
+		testCase{"Resistor", 30, "Resi…"},
+		testCase{"Resistor", 1, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := fit(Regular, 10, testCase.Width, testCase.Text); res != testCase.Result {
+			t.Errorf("Got %q, wants %q", res, testCase.Result)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Fonts of a PDF document, they are standard fonts which need not be
+// embedded
+const (
+	Regular = "F1"
+	Bold    = "F2"
+)
+
+var fontNames = map[string]string{
+	Regular: "Helvetica",
+	Bold:    "Helvetica-Bold",
+}
+
+// A PDF is a document of pages with text and filled rectangles. Coordinates
+// are given in points from the lower left corner of the page.
+type PDF struct {
+	Width  float64
+	Height float64
+	pages  []*bytes.Buffer
+}
+
+// NewPDF returns an empty document with the page size in points
+func NewPDF(width, height float64) *PDF {
+	return &PDF{Width: width, Height: height}
+}
+
+// AddPage begins a new page, which is drawn on by the following calls
+func (p *PDF) AddPage() {
+	p.pages = append(p.pages, new(bytes.Buffer))
+}
+
+func (p *PDF) page() *bytes.Buffer {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+	return p.pages[len(p.pages)-1]
+}
+
+// Rect draws a black filled rectangle
+func (p *PDF) Rect(x, y, width, height float64) {
+	fmt.Fprintf(p.page(), "%.3f %.3f %.3f %.3f re f\n", x, y, width, height)
+}
+
+// Text draws text with its baseline at y
+func (p *PDF) Text(x, y float64, font string, size float64, text string) {
+	fmt.Fprintf(p.page(), "BT /%s %.2f Tf %.3f %.3f Td (%s) Tj ET\n", font, size,
+		x, y, pdfString(winAnsi(text)))
+}
+
+// WriteTo writes the document
+func (p *PDF) WriteTo(w io.Writer) (int64, error) {
+	if len(p.pages) == 0 {
+		p.AddPage()
+	}
+
+	var (
+		buf     bytes.Buffer
+		offsets []int
+	)
+	object := func(content string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), content)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// The catalog, the page tree and the fonts are the objects 1 to 4, the
+	// pages and their contents follow
+	kids := make([]string, len(p.pages))
+	for n := range p.pages {
+		kids[n] = fmt.Sprintf("%d 0 R", 5+2*n)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>",
+		strings.Join(kids, " "), len(p.pages)))
+	for _, font := range []string{Regular, Bold} {
+		object(fmt.Sprintf("<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>",
+			fontNames[font]))
+	}
+	for n, content := range p.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.3f %.3f] "+
+			"/Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			p.Width, p.Height, Regular, Bold, 6+2*n))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.Bytes()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(offsets)+1, xref)
+
+	return buf.WriteTo(w)
+}
+
+// winAnsiReplacements are the characters which are not part of Latin-1 but
+// occur in part names and values
+var winAnsiReplacements = map[rune]string{
+	'Ω': "Ohm",
+	'μ': "\xb5",
+	'€': "\x80",
+	'–': "\x96",
+	'—': "\x97",
+	'•': "\x95",
+	'…': "\x85",
+	'∥': "||",
+	'→': "->",
+}
+
+// winAnsi converts text into the WinAnsi encoding of the standard fonts,
+// unknown characters are replaced by question marks
+func winAnsi(text string) string {
+	var buf bytes.Buffer
+	for _, r := range text {
+		switch {
+		case r < 0x80 || r >= 0xA0 && r <= 0xFF:
+			buf.WriteByte(byte(r))
+		case winAnsiReplacements[r] != "":
+			buf.WriteString(winAnsiReplacements[r])
+		default:
+			buf.WriteByte('?')
+		}
+	}
+	return buf.String()
+}
+
+func pdfString(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// helveticaWidths are the widths of the printable ASCII characters of
+// Helvetica in thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// TextWidth returns the width of text in points. Bold text is estimated
+// from the regular widths.
+func TextWidth(font string, size float64, text string) float64 {
+	width := 0
+	for _, c := range []byte(winAnsi(text)) {
+		if c >= 32 && c < 127 {
+			width += helveticaWidths[c-32]
+		} else {
+			width += 556
+		}
+	}
+	res := float64(width) * size / 1000
+	if font == Bold {
+		res *= 1.08
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPDF(t *testing.T) {
+	pdf := NewPDF(595, 842)
+	pdf.Text(10, 800, Bold, 12, "R (10k)")
+	pdf.Rect(10, 10, 20, 20)
+	pdf.AddPage()
+	pdf.Text(10, 800, Regular, 7, `4.7 kΩ \ 10 μF`)
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	doc := buf.String()
+
+	for _, want := range []string{"%PDF-1.4", "/Count 2", `(R \(10k\)) Tj`,
+		"(4.7 kOhm \\\\ 10 \xb5F) Tj", "10.000 10.000 20.000 20.000 re f", "%%EOF"} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("Document does not contain %q", want)
+		}
+	}
+
+	// The cross-reference table has to point to the objects
+	match := regexp.MustCompile(`startxref\n(\d+)\n`).FindStringSubmatch(doc)
+	if match == nil {
+		t.Fatal("No startxref")
+	}
+	xref, _ := strconv.Atoi(match[1])
+	lines := strings.Split(doc[xref:], "\n")
+	if lines[0] != "xref" {
+		t.Fatalf("Got %q at startxref", lines[0])
+	}
+	var count int
+	fmt.Sscanf(lines[1], "0 %d", &count)
+	if count != 9 {
+		t.Errorf("Got %d objects, wants 9", count)
+	}
+	for n := 1; n < count; n++ {
+		offset, _ := strconv.Atoi(lines[2+n][:10])
+		if want := fmt.Sprintf("%d 0 obj", n); !strings.HasPrefix(doc[offset:], want) {
+			t.Errorf("Offset of object %d points to %q", n, doc[offset:offset+10])
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	type testCase struct {
+		Font   string
+		Size   float64
+		Text   string
+		Result float64
+	}
+
+	testCases := []testCase{
+		testCase{Regular, 10, "", 0},
+		testCase{Regular, 10, "Hi", 9.44},
+		testCase{Regular, 1000, "1 kΩ", 3501},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res := TextWidth(testCase.Font, testCase.Size, testCase.Text)
+		if res < testCase.Result-0.01 || res > testCase.Result+0.01 {
+			t.Errorf("Got %v, wants %v", res, testCase.Result)
+		}
+	}
+}
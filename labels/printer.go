@@ -0,0 +1,70 @@
+package labels
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"time"
+)
+
+// Languages of label printers
+const (
+	ZPL       = "zpl"
+	BrotherQL = "brother-ql"
+)
+
+// ErrUnknownLanguage is returned for printers with an unknown language
+var ErrUnknownLanguage = errors.New("labels: unknown printer language")
+
+// A Printer is a label printer which receives raw print data over TCP,
+// usually on port 9100. ZPL printers print labels of Width × Height
+// millimetres, Brother QL printers print on the Media, which is the name of
+// one of the BrotherMedias, and labels on continuous tapes are Height
+// millimetres long.
+type Printer struct {
+	Address  string
+	Language string
+	Width    float64
+	Height   float64
+	DPI      int
+	Media    string
+}
+
+// SendTimeout is the timeout of connecting to a printer and of sending
+var SendTimeout = 10 * time.Second
+
+// Render returns the print data of the labels
+func (p *Printer) Render(labels []Label) ([]byte, error) {
+	var buf bytes.Buffer
+	switch p.Language {
+	case ZPL:
+		if err := WriteZPL(&buf, labels, p.Width, p.Height, p.DPI); err != nil {
+			return nil, err
+		}
+	case BrotherQL:
+		media, ok := BrotherMedias[p.Media]
+		if !ok {
+			return nil, ErrUnknownMedia
+		}
+		if err := WriteBrotherQL(&buf, labels, media, p.Height); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnknownLanguage
+	}
+	return buf.Bytes(), nil
+}
+
+// Send sends print data to the printer
+func (p *Printer) Send(data []byte) error {
+	conn, err := net.DialTimeout("tcp", p.Address, SendTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(SendTimeout))
+	if _, err := conn.Write(data); err != nil {
+		conn.Close()
+		return err
+	}
+	return conn.Close()
+}
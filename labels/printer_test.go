@@ -0,0 +1,68 @@
+package labels
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+func TestPrinterRender(t *testing.T) {
+	type testCase struct {
+		Printer Printer
+		Err     error
+		Prefix  []byte
+	}
+
+	testCases := []testCase{
+		testCase{Printer{Language: ZPL, Width: 62, Height: 29}, nil, []byte("^XA")},
+		testCase{Printer{Language: BrotherQL, Media: "62x29"}, nil, make([]byte, 200)},
+		testCase{Printer{Language: BrotherQL, Media: "63"}, ErrUnknownMedia, nil},
+		testCase{Printer{Language: "escpos"}, ErrUnknownLanguage, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase.Printer)
+
+		data, err := testCase.Printer.Render([]Label{Label{Title: "Resistor"}})
+		if err != testCase.Err {
+			t.Errorf("Got %v, wants %v", err, testCase.Err)
+		}
+		if !bytes.HasPrefix(data, testCase.Prefix) {
+			t.Errorf("Got data %q", data[:10])
+		}
+	}
+}
+
+func TestPrinterSend(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	received := make(chan []byte)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			received <- nil
+			return
+		}
+		data, _ := ioutil.ReadAll(conn)
+		conn.Close()
+		received <- data
+	}()
+
+	printer := &Printer{Address: l.Addr().String(), Language: ZPL}
+	if err := printer.Send([]byte("^XA^XZ")); err != nil {
+		t.Fatal(err)
+	}
+	if data := <-received; string(data) != "^XA^XZ" {
+		t.Errorf("Got %q, wants %q", data, "^XA^XZ")
+	}
+
+	l.Close()
+	if err := printer.Send([]byte("^XA^XZ")); err == nil {
+		t.Error("Sending to a closed port succeeds")
+	}
+}
@@ -0,0 +1,466 @@
+package labels
+
+import (
+	"errors"
+)
+
+// ErrTooLong is returned when the data does not fit into the largest
+// supported QR code version
+var ErrTooLong = errors.New("labels: data too long for QR code")
+
+// qrVersion describes the error correction blocks of a version at level M
+type qrVersion struct {
+	// EC is the number of error correction codewords per block
+	EC int
+	// Blocks are the numbers of data codewords of the blocks
+	Blocks []int
+	// Align are the positions of the alignment patterns
+	Align []int
+}
+
+// qrVersions are the versions 1 to 10 at error correction level M, which is
+// enough for URLs of about 200 bytes
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+func (v *qrVersion) dataCodewords() int {
+	n := 0
+	for _, size := range v.Blocks {
+		n += size
+	}
+	return n
+}
+
+// A QR is a QR code symbol in byte mode at error correction level M
+type QR struct {
+	Version int
+	Size    int
+	modules [][]bool
+	// function marks the modules of the function patterns
+	function [][]bool
+}
+
+// Dark tells whether the module in column x and row y is dark
+func (q *QR) Dark(x, y int) bool {
+	return q.modules[y][x]
+}
+
+// EncodeQR encodes data with the smallest version which fits
+func EncodeQR(data []byte) (*QR, error) {
+	for n := range qrVersions {
+		version := &qrVersions[n]
+		countBits := 8
+		if n+1 >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+8*len(data) > 8*version.dataCodewords() {
+			continue
+		}
+
+		q := newQR(n + 1)
+		codewords := version.codewords(qrData(data, countBits, version.dataCodewords()))
+		q.drawCodewords(codewords)
+		q.applyBestMask()
+		return q, nil
+	}
+	return nil, ErrTooLong
+}
+
+// qrData returns the data codewords: the byte mode segment, the terminator
+// and the padding
+func qrData(data []byte, countBits int, capacity int) []byte {
+	var bits bitBuffer
+	bits.append(0x4, 4)
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	terminator := 8*capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+
+	res := bits.bytes()
+	for pad := 0; len(res) < capacity; pad++ {
+		res = append(res, []byte{0xEC, 0x11}[pad%2])
+	}
+	return res
+}
+
+type bitBuffer []bool
+
+func (b *bitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, (val>>uint(i))&1 != 0)
+	}
+}
+
+func (b bitBuffer) bytes() []byte {
+	res := make([]byte, len(b)/8)
+	for i, bit := range b {
+		if bit {
+			res[i/8] |= 0x80 >> uint(i%8)
+		}
+	}
+	return res
+}
+
+// codewords splits the data into blocks, adds the error correction codewords
+// and interleaves the blocks
+func (v *qrVersion) codewords(data []byte) []byte {
+	blocks := make([][]byte, len(v.Blocks))
+	ecBlocks := make([][]byte, len(v.Blocks))
+	generator := rsGenerator(v.EC)
+	for n, size := range v.Blocks {
+		blocks[n], data = data[:size], data[size:]
+		ecBlocks[n] = rsRemainder(blocks[n], generator)
+	}
+
+	res := []byte{}
+	for i := 0; i <= v.Blocks[len(v.Blocks)-1]; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				res = append(res, block[i])
+			}
+		}
+	}
+	for i := 0; i < v.EC; i++ {
+		for _, block := range ecBlocks {
+			res = append(res, block[i])
+		}
+	}
+	return res
+}
+
+// gfMul multiplies in GF(256) with the QR code polynomial
+func gfMul(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z&0x80 != 0
+		z <<= 1
+		if carry {
+			z ^= 0x1D
+		}
+		if (y>>uint(i))&1 != 0 {
+			z ^= x
+		}
+	}
+	return z
+}
+
+// rsGenerator returns the coefficients of the Reed-Solomon generator
+// polynomial of the degree, without the leading one
+func rsGenerator(degree int) []byte {
+	res := make([]byte, degree)
+	res[degree-1] = 1
+	var root byte = 1
+	for i := 0; i < degree; i++ {
+		for j := range res {
+			res[j] = gfMul(res[j], root)
+			if j+1 < len(res) {
+				res[j] ^= res[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return res
+}
+
+// rsRemainder returns the error correction codewords of data
+func rsRemainder(data, generator []byte) []byte {
+	res := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ res[0]
+		copy(res, res[1:])
+		res[len(res)-1] = 0
+		for i, coef := range generator {
+			res[i] ^= gfMul(coef, factor)
+		}
+	}
+	return res
+}
+
+// newQR returns a symbol with the function patterns of the version
+func newQR(version int) *QR {
+	size := 17 + 4*version
+	q := &QR{
+		Version:  version,
+		Size:     size,
+		modules:  make([][]bool, size),
+		function: make([][]bool, size),
+	}
+	for y := range q.modules {
+		q.modules[y] = make([]bool, size)
+		q.function[y] = make([]bool, size)
+	}
+
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+
+	for _, pos := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := pos[0]+dx, pos[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				dist := max(abs(dx), abs(dy))
+				q.set(x, y, dist != 2 && dist != 4)
+			}
+		}
+	}
+
+	align := qrVersions[version-1].Align
+	for i, x := range align {
+		for j, y := range align {
+			// The finder patterns take the corners
+			if i == 0 && j == 0 || i == 0 && j == len(align)-1 || i == len(align)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// The format bits are reserved now, and drawn after masking
+	q.drawFormat(0)
+	if version >= 7 {
+		bits := versionBits(version)
+		for i := 0; i < 18; i++ {
+			bit := (bits>>uint(i))&1 != 0
+			a, b := size-11+i%3, i/3
+			q.set(a, b, bit)
+			q.set(b, a, bit)
+		}
+	}
+
+	return q
+}
+
+func (q *QR) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// formatBits returns the format information of level M and the mask
+func formatBits(mask int) int {
+	data := mask // level M is 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits returns the version information of versions 7 and above
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+func (q *QR) drawFormat(mask int) {
+	bits := formatBits(mask)
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 != 0
+	}
+
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		q.set(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.Size-15+i, bit(i))
+	}
+	q.set(8, q.Size-8, true)
+}
+
+// drawCodewords places the codewords in the zigzag order, beginning at the
+// lower right corner
+func (q *QR) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if q.function[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				q.modules[y][x] = (codewords[i/8]>>uint(7-i%8))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// applyMask inverts the data modules of the mask, applying it twice undoes it
+func (q *QR) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if !q.function[y][x] && maskBit(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask applies the mask with the least penalty
+func (q *QR) applyBestMask() {
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(mask)
+		if penalty := q.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(best)
+}
+
+// penalty rates the symbol with the four rules of the specification
+func (q *QR) penalty() int {
+	penalty, dark := 0, 0
+	finder := []bool{true, false, true, true, true, false, true}
+
+	line := make([]bool, q.Size)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < q.Size; a++ {
+			for b := 0; b < q.Size; b++ {
+				if vertical {
+					line[b] = q.modules[b][a]
+				} else {
+					line[b] = q.modules[a][b]
+				}
+			}
+
+			// Runs of five or more modules of the same color
+			run := 1
+			for b := 1; b <= q.Size; b++ {
+				if b < q.Size && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+
+			// Finder-like patterns with four light modules on one side
+			for b := 0; b+7 <= q.Size; b++ {
+				match := true
+				for i, m := range finder {
+					if line[b+i] != m {
+						match = false
+						break
+					}
+				}
+				if match && (lightRun(line, b-4, b) || lightRun(line, b+7, b+11)) {
+					penalty += 40
+				}
+			}
+		}
+	}
+
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	total := q.Size * q.Size
+	deviation := abs(dark*20-total*10) / total
+	return penalty + deviation*10
+}
+
+// lightRun tells whether the modules from i to j are light, modules outside
+// of the symbol are light
+func lightRun(line []bool, i, j int) bool {
+	for ; i < j; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,195 @@
+package labels
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// The example of "HELLO WORLD" at version 1-M
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	ec := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	res := rsRemainder(data, rsGenerator(10))
+	if !bytes.Equal(res, ec) {
+		t.Errorf("Got %v, wants %v", res, ec)
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	type testCase struct {
+		Mask   int
+		Result int
+	}
+
+	testCases := []testCase{
+		testCase{0, 0x5412},
+		testCase{5, 0x40CE},
+		testCase{7, 0x4AA0},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := formatBits(testCase.Mask); res != testCase.Result {
+			t.Errorf("Got %#x, wants %#x", res, testCase.Result)
+		}
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	type testCase struct {
+		Version int
+		Result  int
+	}
+
+	testCases := []testCase{
+		testCase{7, 0x07C94},
+		testCase{8, 0x085BC},
+		testCase{10, 0x0A4D3},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := versionBits(testCase.Version); res != testCase.Result {
+			t.Errorf("Got %#x, wants %#x", res, testCase.Result)
+		}
+	}
+}
+
+// readQR reads the data of a symbol back, it checks the error correction
+// codewords instead of correcting errors
+func readQR(t *testing.T, q *QR) []byte {
+	// The format information is read from the first copy
+	format := 0
+	bit := func(x, y int, i int) {
+		if q.Dark(x, y) {
+			format |= 1 << uint(i)
+		}
+	}
+	for i := 0; i <= 5; i++ {
+		bit(8, i, i)
+	}
+	bit(8, 7, 6)
+	bit(8, 8, 7)
+	bit(7, 8, 8)
+	for i := 9; i < 15; i++ {
+		bit(14-i, 8, i)
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("invalid format information %#x", format)
+	}
+
+	ref := newQR(q.Version)
+	version := &qrVersions[q.Version-1]
+	total := version.dataCodewords() + version.EC*len(version.Blocks)
+	codewords := make([]byte, total)
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if ref.function[y][x] || i >= total*8 {
+					continue
+				}
+				if q.Dark(x, y) != maskBit(mask, x, y) {
+					codewords[i/8] |= 0x80 >> uint(i%8)
+				}
+				i++
+			}
+		}
+	}
+
+	// The blocks are deinterleaved and checked
+	blocks := make([][]byte, len(version.Blocks))
+	n := 0
+	for i := 0; i <= version.Blocks[len(version.Blocks)-1]; i++ {
+		for b, size := range version.Blocks {
+			if i < size {
+				blocks[b] = append(blocks[b], codewords[n])
+				n++
+			}
+		}
+	}
+	data := []byte{}
+	for b, block := range blocks {
+		ec := make([]byte, version.EC)
+		for i := range ec {
+			ec[i] = codewords[n+i*len(blocks)+b]
+		}
+		if !bytes.Equal(rsRemainder(block, rsGenerator(version.EC)), ec) {
+			t.Errorf("block %d has invalid error correction codewords", b)
+		}
+		data = append(data, block...)
+	}
+
+	// The segment is a byte mode segment
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("invalid mode %#x", data[0]>>4)
+	}
+	var bits bitBuffer
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+	countBits := 8
+	if q.Version >= 10 {
+		countBits = 16
+	}
+	length := 0
+	for _, b := range bits[4 : 4+countBits] {
+		length <<= 1
+		if b {
+			length |= 1
+		}
+	}
+	return bits[4+countBits : 4+countBits+8*length].bytes()
+}
+
+func TestEncodeQR(t *testing.T) {
+	type testCase struct {
+		Data    string
+		Version int
+	}
+
+	testCases := []testCase{
+		testCase{"", 1},
+		testCase{"http://localhost:8901/parts/1", 3},
+		testCase{"http://inventory.example.org/places/edit/123456", 4},
+		testCase{strings.Repeat("x", 150), 8},
+		testCase{strings.Repeat("y", 200), 10},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		q, err := EncodeQR([]byte(testCase.Data))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if q.Version != testCase.Version || q.Size != 17+4*testCase.Version {
+			t.Errorf("Got version %d of size %d, wants %d", q.Version, q.Size, testCase.Version)
+		}
+		if res := string(readQR(t, q)); res != testCase.Data {
+			t.Errorf("Got %q, wants %q", res, testCase.Data)
+		}
+	}
+
+	if _, err := EncodeQR(make([]byte, 300)); err != ErrTooLong {
+		t.Errorf("Got %v, wants %v", err, ErrTooLong)
+	}
+}
@@ -0,0 +1,97 @@
+package labels
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DefaultZPLDPI is the resolution of most Zebra printers
+const DefaultZPLDPI = 203
+
+// zplEscape escapes the field data for the ^FH command
+var zplEscape = strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E")
+
+// WriteZPL renders every label as a ZPL format of width × height millimetres
+// with the resolution in dots per inch. Text is printed with the scalable
+// printer font, the QR code is sent as graphic field.
+func WriteZPL(buf *bytes.Buffer, labels []Label, width, height float64, dpi int) error {
+	if width <= 0 || height <= 0 {
+		return ErrInvalidLayout
+	}
+	if dpi <= 0 {
+		dpi = DefaultZPLDPI
+	}
+	dotsPerMM := float64(dpi) / 25.4
+	w, h := int(width*dotsPerMM), int(height*dotsPerMM)
+	pad := int(2 * dotsPerMM)
+
+	for _, label := range labels {
+		fmt.Fprintf(buf, "^XA^CI28^PW%d^LL%d^LH0,0\n", w, h)
+
+		textX := pad
+		if label.URL != "" {
+			qr, err := EncodeQR([]byte(label.URL))
+			if err != nil {
+				return err
+			}
+			side := minInt(h-2*pad, w/2)
+			module := side / (qr.Size + 2*quietZone)
+			if module < 1 {
+				module = 1
+			}
+			writeZPLGraphic(buf, pad, pad, qrBitmap(qr, module))
+			textX += side + pad
+		}
+		textWidth := w - pad - textX
+
+		// The font sizes follow the PDF labels, in dots
+		scale := math.Min(1, height/38+0.25)
+		title := int(titleSize * scale * float64(dpi) / 72)
+		size := int(lineSize * scale * float64(dpi) / 72)
+
+		y := pad
+		writeZPLText(buf, textX, y, title, fit(Bold, float64(title), float64(textWidth), label.Title))
+		y += title + title*4/10
+		for _, line := range label.Lines {
+			if y+size > h-pad {
+				break
+			}
+			writeZPLText(buf, textX, y, size, fit(Regular, float64(size), float64(textWidth), line))
+			y += size * 12 / 10
+		}
+
+		buf.WriteString("^XZ\n")
+	}
+	return nil
+}
+
+func writeZPLText(buf *bytes.Buffer, x, y, size int, text string) {
+	fmt.Fprintf(buf, "^FO%d,%d^A0N,%d,%d^FH^FD%s^FS\n", x, y, size, size, zplEscape.Replace(text))
+}
+
+// writeZPLGraphic writes a bitmap as ASCII hex graphic field
+func writeZPLGraphic(buf *bytes.Buffer, x, y int, b *Bitmap) {
+	rowBytes := (b.Width + 7) / 8
+	fmt.Fprintf(buf, "^FO%d,%d^GFA,%d,%d,%d,", x, y, rowBytes*b.Height, rowBytes*b.Height, rowBytes)
+	for row := 0; row < b.Height; row++ {
+		fmt.Fprintf(buf, "%X", b.Row(row))
+	}
+	buf.WriteString("^FS\n")
+}
+
+// qrBitmap returns the symbol with its quiet zone
+func qrBitmap(qr *QR, module int) *Bitmap {
+	side := (qr.Size + 2*quietZone) * module
+	b := NewBitmap(side, side)
+	b.DrawQR(qr, 0, 0, module)
+	return b
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
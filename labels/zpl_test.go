@@ -0,0 +1,44 @@
+package labels
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteZPL(t *testing.T) {
+	labels := []Label{
+		Label{Title: "Resistor ^1", Lines: []string{"4.7 kΩ", "Drawer_12"},
+			URL: "http://localhost:8901/parts/1"},
+		Label{Title: "Shelf"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteZPL(&buf, labels, 62, 29, 203); err != nil {
+		t.Fatal(err)
+	}
+	res := buf.String()
+
+	if n := strings.Count(res, "^XA"); n != 2 || strings.Count(res, "^XZ") != 2 {
+		t.Errorf("Got %d formats, wants 2", n)
+	}
+	for _, want := range []string{"^PW495^LL231", "^FDResistor _5E1^FS", "^FD4.7 kΩ^FS",
+		"^FDDrawer_5F12^FS", "^FDShelf^FS"} {
+		if !strings.Contains(res, want) {
+			t.Errorf("Output does not contain %q", want)
+		}
+	}
+
+	// The QR code of version 3 has 29 modules and a quiet zone of 2 modules on
+	// both sides, each module is 6 dots
+	if n := strings.Count(res, "^GFA"); n != 1 {
+		t.Errorf("Got %d graphic fields, wants 1", n)
+	}
+	if !strings.Contains(res, "^FO15,15^GFA,4950,4950,25,") {
+		t.Error("Graphic field has an invalid size")
+	}
+
+	if err := WriteZPL(&buf, labels, 0, 29, 203); err != ErrInvalidLayout {
+		t.Errorf("Got %v, wants %v", err, ErrInvalidLayout)
+	}
+}
@@ -0,0 +1,47 @@
+package inventory
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/fritz0705/inventory/si"
+)
+
+func TestPartLabel(t *testing.T) {
+	type testCase struct {
+		Part  PartView
+		Lines []string
+	}
+
+	part := func(value float64, prefix sql.NullInt64) PartView {
+		p := PartView{
+			CategoryName:  "Resistors",
+			UnitSymbol:    sql.NullString{String: "Ω", Valid: true},
+			DisplayPrefix: prefix,
+		}
+		p.Id, p.Name = 1, "R"
+		p.Value = sql.NullFloat64{Float64: value, Valid: value != 0}
+		return p
+	}
+
+	testCases := []testCase{
+		testCase{part(4700, sql.NullInt64{}), []string{"4.7kΩ", "Resistors"}},
+		testCase{part(4700, sql.NullInt64{Int64: int64(si.None), Valid: true}),
+			[]string{"4.7kΩ", "Resistors"}},
+		testCase{part(0, sql.NullInt64{}), []string{"Resistors"}},
+	}
+
+	app := NewApplication()
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		label := app.partLabel(&testCase.Part, "http://localhost")
+		if !reflect.DeepEqual(label.Lines, testCase.Lines) {
+			t.Errorf("Got %q", label.Lines)
+		}
+		if label.URL != "http://localhost/parts/1" {
+			t.Errorf("Got URL %s", label.URL)
+		}
+	}
+}
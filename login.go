@@ -174,3 +174,17 @@ func (app *Application) SettingsHandler(w http.ResponseWriter, r *http.Request)
 
 	app.renderTemplate(w, r, user, "Settings", "Layout")
 }
+
+// currentUserId returns the id of the user logged in with the request's
+// session
+func (app *Application) currentUserId(r *http.Request) (int64, bool) {
+	if app.Sessions == nil {
+		return 0, false
+	}
+	session, err := app.Sessions.Get(r, app.SessionName)
+	if err != nil {
+		return 0, false
+	}
+	userId, ok := session.Values["userId"].(int64)
+	return userId, ok
+}
@@ -4,8 +4,10 @@ import (
 	"crypto/rand"
 	"crypto/subtle"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime"
 	"net/url"
 	"strconv"
@@ -52,6 +54,15 @@ type (
 		Key           string  `db:"key"`
 	}
 
+	// A Packaging is a package in which a part is received, like a reel of
+	// 5000 pieces. The quantity is given in the stock unit of the part.
+	Packaging struct {
+		Id       int64   `db:"id"`
+		PartId   int64   `db:"part_id"`
+		Name     string  `db:"name"`
+		Quantity float64 `db:"quantity"`
+	}
+
 	DistributorPartView struct {
 		DistributorPart
 		Name     string `db:"name"`
@@ -65,6 +76,13 @@ type (
 		Name        string          `db:"name"`
 		Description sql.NullString  `db:"description"`
 		Value       sql.NullFloat64 `db:"value"`
+		Decimal     sql.NullString  `db:"value_decimal"`
+		Tolerance   sql.NullString  `db:"tolerance"`
+		ValueMin    sql.NullFloat64 `db:"value_min"`
+		ValueMax    sql.NullFloat64 `db:"value_max"`
+		UnitMass    sql.NullFloat64 `db:"unit_mass"`
+		TareMass    sql.NullFloat64 `db:"tare_mass"`
+		StockUnit   sql.NullString  `db:"stock_unit"`
 		CategoryId  int64           `db:"category_id"`
 		PlaceId     sql.NullInt64   `db:"place_id"`
 		OwnerId     sql.NullInt64   `db:"owner_id"`
@@ -74,26 +92,28 @@ type (
 
 	PartView struct {
 		Part
-		CategoryName string         `db:"category_name"`
-		Unit         sql.NullString `db:"unit"`
-		UnitSymbol   sql.NullString `db:"unit_symbol"`
-		PlaceName    sql.NullString `db:"place_name"`
-		Amount       int64          `db:"amount"`
-		ImageKey     []byte         `db:"image_key"`
+		CategoryName  string         `db:"category_name"`
+		Unit          sql.NullString `db:"unit"`
+		UnitSymbol    sql.NullString `db:"unit_symbol"`
+		DisplayPrefix sql.NullInt64  `db:"display_prefix"`
+		PlaceName     sql.NullString `db:"place_name"`
+		Amount        float64        `db:"amount"`
+		ImageKey      []byte         `db:"image_key"`
 	}
 
 	Category struct {
-		Id         int64          `db:"id"`
-		Name       string         `db:"name"`
-		Unit       sql.NullString `db:"unit"`
-		UnitSymbol sql.NullString `db:"unit_symbol"`
-		ParentId   sql.NullInt64  `db:"parent_id"`
+		Id            int64          `db:"id"`
+		Name          string         `db:"name"`
+		Unit          sql.NullString `db:"unit"`
+		UnitSymbol    sql.NullString `db:"unit_symbol"`
+		ParentId      sql.NullInt64  `db:"parent_id"`
+		DisplayPrefix sql.NullInt64  `db:"display_prefix"`
 	}
 
 	PartAmount struct {
 		Id        int64     `db:"id"`
 		PartId    int64     `db:"part_id"`
-		Amount    int64     `db:"amount"`
+		Amount    float64   `db:"amount"`
 		Timestamp time.Time `db:"timestamp"`
 	}
 
@@ -110,6 +130,29 @@ type (
 		CreatedAt time.Time `db:"created_at"`
 		PartId    int64     `db:"part_id"`
 	}
+
+	Tag struct {
+		Id   int64  `db:"id"`
+		Name string `db:"name"`
+	}
+
+	SavedView struct {
+		Id        int64         `db:"id"`
+		Name      string        `db:"name"`
+		Kind      string        `db:"kind"`
+		Query     string        `db:"query"`
+		OwnerId   sql.NullInt64 `db:"owner_id"`
+		Shared    bool          `db:"shared"`
+		Dashboard bool          `db:"dashboard"`
+		CreatedAt time.Time     `db:"created_at"`
+	}
+)
+
+// Kinds of saved views. A parts view stores the URL-encoded partsFilter of
+// the parts list, a search view stores the query string of the search box.
+const (
+	SavedViewParts  = "parts"
+	SavedViewSearch = "search"
 )
 
 func (a *Attachment) MediaType() string {
@@ -231,13 +274,35 @@ func LoadParts(rows *sql.Rows) ([]*Part, error) {
 }
 
 func (p *Part) Save(db Execer) error {
+	// The decimal representation is derived from the value, and used for exact
+	// comparisons
+	p.Decimal = sql.NullString{Valid: p.Value.Valid}
+	if p.Value.Valid {
+		p.Decimal.String = si.New(p.Value.Float64).Decimal()
+	}
+
+	// The tolerance band is stored, so it can be compared in queries
+	p.ValueMin, p.ValueMax = sql.NullFloat64{}, sql.NullFloat64{}
+	if p.Value.Valid && p.Tolerance.Valid {
+		tolerance, err := si.ParseTolerance(p.Tolerance.String)
+		if err != nil {
+			return err
+		}
+		band := tolerance.Band(si.New(p.Value.Float64))
+		p.ValueMin = sql.NullFloat64{Float64: band.Low, Valid: true}
+		p.ValueMax = sql.NullFloat64{Float64: band.High, Valid: true}
+	}
+
 	if p.Id == 0 {
 		// CREATE
 		res, err := db.Exec(`INSERT INTO 'part' ('name', 'description', 'value',
-		'category_id', 'owner_id', 'place_id', 'created_at', 'image_id')
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-			p.Name, p.Description, p.Value, p.CategoryId, p.OwnerId, p.PlaceId,
-			p.CreatedAt, p.ImageId)
+		'value_decimal', 'tolerance', 'value_min', 'value_max', 'unit_mass',
+		'tare_mass', 'stock_unit', 'category_id', 'owner_id', 'place_id',
+		'created_at', 'image_id')
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			p.Name, p.Description, p.Value, p.Decimal, p.Tolerance, p.ValueMin,
+			p.ValueMax, p.UnitMass, p.TareMass, p.StockUnit, p.CategoryId,
+			p.OwnerId, p.PlaceId, p.CreatedAt, p.ImageId)
 		if err != nil {
 			return err
 		}
@@ -247,10 +312,13 @@ func (p *Part) Save(db Execer) error {
 
 	// UPDATE
 	_, err := db.Exec(`UPDATE 'part' SET 'name' = ?, 'description' = ?,
-	'value' = ?, 'category_id' = ?, 'owner_id' = ?, 'place_id' = ?,
-	'created_at' = ?, 'image_id' = ?
-	WHERE "id" = ?`, p.Name, p.Description, p.Value, p.CategoryId, p.OwnerId,
-		p.PlaceId, p.CreatedAt, p.ImageId, p.Id)
+	'value' = ?, 'value_decimal' = ?, 'tolerance' = ?, 'value_min' = ?,
+	'value_max' = ?, 'unit_mass' = ?, 'tare_mass' = ?, 'stock_unit' = ?,
+	'category_id' = ?, 'owner_id' = ?, 'place_id' = ?, 'created_at' = ?,
+	'image_id' = ?
+	WHERE "id" = ?`, p.Name, p.Description, p.Value, p.Decimal, p.Tolerance,
+		p.ValueMin, p.ValueMax, p.UnitMass, p.TareMass, p.StockUnit,
+		p.CategoryId, p.OwnerId, p.PlaceId, p.CreatedAt, p.ImageId, p.Id)
 
 	return err
 }
@@ -271,6 +339,20 @@ func (p *Part) Load(rows *sql.Rows) error {
 			dest[n] = &p.Description
 		case "value":
 			dest[n] = &p.Value
+		case "value_decimal":
+			dest[n] = &p.Decimal
+		case "tolerance":
+			dest[n] = &p.Tolerance
+		case "value_min":
+			dest[n] = &p.ValueMin
+		case "value_max":
+			dest[n] = &p.ValueMax
+		case "unit_mass":
+			dest[n] = &p.UnitMass
+		case "tare_mass":
+			dest[n] = &p.TareMass
+		case "stock_unit":
+			dest[n] = &p.StockUnit
 		case "category_id":
 			dest[n] = &p.CategoryId
 		case "owner_id":
@@ -285,6 +367,29 @@ func (p *Part) Load(rows *sql.Rows) error {
 	return rows.Scan(dest...)
 }
 
+// CheckValue checks that the value of a part form matches the unit of the
+// part's category
+func (p *Part) CheckValue(db Queryer, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	category, err := p.Category(db)
+	if err != nil || category == nil {
+		return err
+	}
+
+	_, err = si.ParseQuantityIn(value, category.UnitSymbol.String)
+	if _, ok := err.(*si.UnknownPrefixError); ok {
+		// Numbers with a binary prefix have no unit
+		_, err = si.ParseBinary(value)
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
 func (p *Part) LoadForm(form url.Values) error {
 	for key, value := range form {
 		switch key {
@@ -296,7 +401,7 @@ func (p *Part) LoadForm(form url.Values) error {
 				Valid:  value[0] != "",
 			}
 		case "value":
-			num, err := si.Parse(value[0])
+			num, err := si.ParseQuantityBinary(value[0])
 			if err != nil && err != io.EOF {
 				return err
 			}
@@ -304,6 +409,35 @@ func (p *Part) LoadForm(form url.Values) error {
 				Float64: num.Value(),
 				Valid:   value[0] != "",
 			}
+		case "tolerance":
+			tolerance, err := si.ParseTolerance(value[0])
+			if err != nil && value[0] != "" {
+				return err
+			}
+			p.Tolerance = sql.NullString{
+				String: tolerance.String(),
+				Valid:  value[0] != "" && !tolerance.IsZero(),
+			}
+		case "unit_mass":
+			mass, err := ParseMass(value[0])
+			if err != nil {
+				return err
+			}
+			p.UnitMass = mass
+		case "tare_mass":
+			mass, err := ParseMass(value[0])
+			if err != nil {
+				return err
+			}
+			p.TareMass = mass
+		case "stock_unit":
+			if !isStockUnit(value[0]) && value[0] != "" {
+				return fmt.Errorf("invalid stock unit %q", value[0])
+			}
+			p.StockUnit = sql.NullString{
+				String: value[0],
+				Valid:  value[0] != "" && value[0] != DefaultStockUnit,
+			}
 		case "category":
 			val, err := strconv.Atoi(value[0])
 			if err != nil {
@@ -342,6 +476,51 @@ func (p *Part) LoadForm(form url.Values) error {
 	return nil
 }
 
+var (
+	ErrNoUnitMass    = errors.New("inventory: part has no unit mass")
+	ErrInvalidWeight = errors.New("inventory: invalid weight")
+)
+
+// ParseMass parses a mass in grams, e.g. "12.5g" or "1.2kg". An empty string
+// yields NULL.
+func ParseMass(s string) (sql.NullFloat64, error) {
+	if s == "" {
+		return sql.NullFloat64{}, nil
+	}
+
+	mass, err := si.ParseQuantityIn(s, "g")
+	if err != nil && err != io.EOF {
+		return sql.NullFloat64{}, err
+	}
+	return sql.NullFloat64{Float64: mass.Value(), Valid: true}, nil
+}
+
+// CountByWeight computes the number of parts from a gross weight in grams. If
+// tare is not valid, the tare mass of the part is subtracted.
+func (p *Part) CountByWeight(gross float64, tare sql.NullFloat64) (float64, error) {
+	if !p.UnitMass.Valid || p.UnitMass.Float64 <= 0 {
+		return 0, ErrNoUnitMass
+	}
+	if !tare.Valid {
+		tare = p.TareMass
+	}
+
+	net := gross - tare.Float64
+	if net < 0 {
+		return 0, ErrInvalidWeight
+	}
+	return math.Floor(net/p.UnitMass.Float64 + 0.5), nil
+}
+
+// Calibrate sets the unit mass of the part from the net weight of count parts
+func (p *Part) Calibrate(count int64, weight float64) error {
+	if count <= 0 || weight <= 0 {
+		return ErrInvalidWeight
+	}
+	p.UnitMass = sql.NullFloat64{Float64: weight / float64(count), Valid: true}
+	return nil
+}
+
 func (p *Part) LatestAmount(db Queryer) (*PartAmount, error) {
 	rows, err := db.Query(`SELECT * FROM 'part_amount' WHERE "part_id" = ? ORDER BY "timestamp" DESC LIMIT 1`, p.Id)
 	if err != nil {
@@ -393,8 +572,8 @@ func (p *Part) Place(db Queryer) (*Place, error) {
 func (c *Category) Save(db Execer) error {
 	if c.Id == 0 {
 		res, err := db.Exec(`INSERT INTO 'category' ('name', 'unit', 'unit_symbol',
-	'parent_id') VALUES (?, ?, ?, ?)`,
-			c.Name, c.Unit, c.UnitSymbol, c.ParentId)
+	'parent_id', 'display_prefix') VALUES (?, ?, ?, ?, ?)`,
+			c.Name, c.Unit, c.UnitSymbol, c.ParentId, c.DisplayPrefix)
 		if err != nil {
 			return err
 		}
@@ -404,8 +583,8 @@ func (c *Category) Save(db Execer) error {
 
 	// UPDATE
 	_, err := db.Exec(`UPDATE 'category' SET 'name' = ?, 'unit' = ?,
-	'unit_symbol' = ?, 'parent_id' = ? WHERE "id" = ?`,
-		c.Name, c.Unit, c.UnitSymbol, c.ParentId, c.Id)
+	'unit_symbol' = ?, 'parent_id' = ?, 'display_prefix' = ? WHERE "id" = ?`,
+		c.Name, c.Unit, c.UnitSymbol, c.ParentId, c.DisplayPrefix, c.Id)
 
 	return err
 }
@@ -428,6 +607,8 @@ func (c *Category) Load(rows *sql.Rows) error {
 			dest[n] = &c.UnitSymbol
 		case "parent_id":
 			dest[n] = &c.ParentId
+		case "display_prefix":
+			dest[n] = &c.DisplayPrefix
 		}
 	}
 
@@ -436,6 +617,12 @@ func (c *Category) Load(rows *sql.Rows) error {
 
 func (c *Category) LoadForm(form url.Values) error {
 	for key, value := range form {
+		// An empty display prefix selects the best prefix for each value
+		if key == "display_prefix" {
+			val, err := strconv.Atoi(value[0])
+			c.DisplayPrefix = sql.NullInt64{Int64: int64(val), Valid: err == nil}
+			continue
+		}
 		if value[0] == "" {
 			continue
 		}
@@ -448,8 +635,9 @@ func (c *Category) LoadForm(form url.Values) error {
 				Valid:  true,
 			}
 		case "unit_symbol":
+			// Symbols are compared with the units of searches
 			c.UnitSymbol = sql.NullString{
-				String: value[0],
+				String: si.NormalizeUnit(value[0]),
 				Valid:  true,
 			}
 		case "parent":
@@ -554,6 +742,25 @@ func (d *DistributorPartView) PartURL() string {
 	return fmt.Sprintf(d.URL, url.QueryEscape(d.Key))
 }
 
+func (d *Distributor) Save(db Execer) error {
+	if d.Id == 0 {
+		// CREATE
+		res, err := db.Exec(`INSERT INTO 'distributor' ('name', 'url', 'homepage',
+		'api') VALUES (?, ?, ?, ?)`, d.Name, d.URL, d.Homepage, d.Api)
+		if err != nil {
+			return err
+		}
+
+		d.Id, err = res.LastInsertId()
+		return err
+	}
+
+	_, err := db.Exec(`UPDATE 'distributor' SET 'name' = ?, 'url' = ?,
+	'homepage' = ?, 'api' = ? WHERE "id" = ?`, d.Name, d.URL, d.Homepage, d.Api,
+		d.Id)
+	return err
+}
+
 func (d *DistributorPart) Save(db Execer) error {
 	if d.Id == 0 {
 		// CREATE
@@ -595,3 +802,117 @@ func (d *DistributorPart) LoadForm(form url.Values) error {
 	}
 	return nil
 }
+
+func (p *Packaging) Save(db Execer) error {
+	if p.Id == 0 {
+		// CREATE
+		res, err := db.Exec(`INSERT INTO 'packaging' ('part_id', 'name', 'quantity')
+		VALUES (?, ?, ?)`, p.PartId, p.Name, p.Quantity)
+		if err != nil {
+			return err
+		}
+
+		p.Id, err = res.LastInsertId()
+		return err
+	}
+
+	_, err := db.Exec(`UPDATE 'packaging' SET 'part_id' = ?, 'name' = ?,
+	'quantity' = ? WHERE "id" = ?`, p.PartId, p.Name, p.Quantity, p.Id)
+	return err
+}
+
+// LoadForm loads the name of the packaging, the quantity depends on the stock
+// unit of the part
+func (p *Packaging) LoadForm(form url.Values) error {
+	for key, value := range form {
+		switch key {
+		case "name":
+			p.Name = strings.TrimSpace(value[0])
+		}
+	}
+	return nil
+}
+
+func (v *SavedView) Save(db Execer) error {
+	if v.Id == 0 {
+		// CREATE
+		res, err := db.Exec(`INSERT INTO 'saved_view' ('name', 'kind', 'query',
+		'owner_id', 'shared', 'dashboard', 'created_at') VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			v.Name, v.Kind, v.Query, v.OwnerId, v.Shared, v.Dashboard, v.CreatedAt)
+		if err != nil {
+			return err
+		}
+		v.Id, err = res.LastInsertId()
+		return err
+	}
+
+	// UPDATE
+	_, err := db.Exec(`UPDATE 'saved_view' SET 'name' = ?, 'kind' = ?, 'query' = ?,
+	'owner_id' = ?, 'shared' = ?, 'dashboard' = ?, 'created_at' = ? WHERE "id" = ?`,
+		v.Name, v.Kind, v.Query, v.OwnerId, v.Shared, v.Dashboard, v.CreatedAt, v.Id)
+
+	return err
+}
+
+func (v *SavedView) LoadForm(form url.Values) error {
+	for key, value := range form {
+		switch key {
+		case "name":
+			v.Name = value[0]
+		case "kind":
+			switch value[0] {
+			case SavedViewParts, SavedViewSearch:
+				v.Kind = value[0]
+			default:
+				return fmt.Errorf("invalid view kind %q", value[0])
+			}
+		case "query":
+			v.Query = value[0]
+		case "shared":
+			v.Shared = value[0] != ""
+		case "dashboard":
+			v.Dashboard = value[0] != ""
+		}
+	}
+	return nil
+}
+
+// URL returns the stable URL of a saved view
+func (v *SavedView) URL() string {
+	return fmt.Sprintf("/views/%d", v.Id)
+}
+
+// TargetURL returns the URL of the parts list or search page the view was
+// saved from
+func (v *SavedView) TargetURL() string {
+	if v.Kind == SavedViewSearch {
+		return "/search?" + url.Values{"query": {v.Query}}.Encode()
+	}
+	return "/parts?" + v.Query
+}
+
+func (t *Tag) Save(db Execer) error {
+	if t.Id == 0 {
+		res, err := db.Exec(`INSERT INTO 'tag' ('name') VALUES (?)`, t.Name)
+		if err != nil {
+			return err
+		}
+		t.Id, err = res.LastInsertId()
+		return err
+	}
+
+	_, err := db.Exec(`UPDATE 'tag' SET 'name' = ? WHERE "id" = ?`, t.Name, t.Id)
+	return err
+}
+
+func (p *Part) AddTag(db Execer, tag *Tag) error {
+	_, err := db.Exec(`INSERT OR IGNORE INTO 'part_tag' ('part_id', 'tag_id')
+	VALUES (?, ?)`, p.Id, tag.Id)
+	return err
+}
+
+func (p *Part) RemoveTag(db Execer, tag *Tag) error {
+	_, err := db.Exec(`DELETE FROM 'part_tag' WHERE "part_id" = ? AND "tag_id" = ?`,
+		p.Id, tag.Id)
+	return err
+}
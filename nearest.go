@@ -0,0 +1,45 @@
+package inventory
+
+import (
+	"math"
+
+	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
+)
+
+type nearestPart struct {
+	PartView
+	Series si.Series
+}
+
+// nearestStock returns the part in stock with the standard value nearest to
+// val, which is matched by where. Only parts within one decade of val are
+// considered, and nil is returned if there is none.
+func nearestStock(db sqlx.Queryer, val float64, where string, args []interface{}) (*nearestPart, error) {
+	if val <= 0 {
+		return nil, nil
+	}
+
+	parts := []PartView{}
+	err := sqlx.Select(db, &parts, `SELECT * FROM 'part_view' WHERE `+where+`
+	AND "amount" > 0 AND "value" BETWEEN ? AND ?`,
+		append(args, val/10, val*10)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var nearest *nearestPart
+	bestDist := math.Inf(1)
+	for _, part := range parts {
+		series, ok := si.SeriesOf(si.New(part.Value.Float64))
+		if !ok {
+			continue
+		}
+		dist := math.Abs(math.Log(part.Value.Float64 / val))
+		if dist < bestDist {
+			nearest = &nearestPart{part, series}
+			bestDist = dist
+		}
+	}
+	return nearest, nil
+}
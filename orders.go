@@ -0,0 +1,497 @@
+package inventory
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// An OrderFormat describes the CSV export of an order of a distributor. The
+// columns are given as the possible headers in lower case.
+type OrderFormat struct {
+	// Distributor is the name of the distributor, as in distributors.sql
+	Distributor string
+	Comma       rune
+	// DecimalComma is set for numbers like "1.234,50"
+	DecimalComma bool
+	Key          []string
+	Quantity     []string
+	Price        []string
+	Description  []string
+}
+
+// OrderFormats are the known order formats by name
+var OrderFormats = map[string]*OrderFormat{
+	"reichelt": {
+		Distributor:  "Reichelt Elektronik",
+		Comma:        ';',
+		DecimalComma: true,
+		Key:          []string{"artikelnr.", "artikel-nr.", "artikelnummer", "bestellnummer"},
+		Quantity:     []string{"menge", "anzahl", "stück"},
+		Price:        []string{"einzelpreis", "preis", "stückpreis"},
+		Description:  []string{"bezeichnung", "artikelbezeichnung", "beschreibung"},
+	},
+	"farnell": {
+		Distributor: "Farnell DE",
+		Comma:       ',',
+		Key:         []string{"order code", "farnell order code"},
+		Quantity:    []string{"quantity"},
+		Price:       []string{"unit price"},
+		Description: []string{"description"},
+	},
+	"farnell-de": {
+		Distributor:  "Farnell DE",
+		Comma:        ';',
+		DecimalComma: true,
+		Key:          []string{"bestellnummer", "farnell-bestellnummer", "artikelnummer"},
+		Quantity:     []string{"menge"},
+		Price:        []string{"stückpreis", "einzelpreis"},
+		Description:  []string{"beschreibung"},
+	},
+	"pollin": {
+		Distributor:  "Pollin",
+		Comma:        ';',
+		DecimalComma: true,
+		Key:          []string{"bestell-nr.", "best.-nr.", "bestellnummer", "artikelnummer", "art.-nr."},
+		Quantity:     []string{"menge", "anzahl"},
+		Price:        []string{"einzelpreis", "preis"},
+		Description:  []string{"artikelbezeichnung", "bezeichnung", "beschreibung"},
+	},
+}
+
+// orderFormatNames returns the names of OrderFormats in order
+func orderFormatNames() []string {
+	names := make([]string, 0, len(OrderFormats))
+	for name := range OrderFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// An OrderLine is one ordered item
+type OrderLine struct {
+	Line        int
+	Key         string
+	Quantity    float64
+	Price       sql.NullFloat64
+	Description string
+}
+
+// ParseOrder reads the lines of an order. Lines before the header, which is
+// the first line with a key column, and lines without key, like totals, are
+// skipped.
+func ParseOrder(r io.Reader, format *OrderFormat) ([]OrderLine, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = format.Comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var columns map[string]int
+	lines := []OrderLine{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if columns == nil {
+			columns = orderColumns(record, format)
+			if columns != nil {
+				if _, ok := columns["quantity"]; !ok {
+					return nil, errors.New("order has no quantity column")
+				}
+			}
+			continue
+		}
+
+		field := func(name string) string {
+			n, ok := columns[name]
+			if !ok || n >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[n])
+		}
+
+		orderLine := OrderLine{
+			Line:        line,
+			Key:         field("key"),
+			Description: field("description"),
+		}
+		if orderLine.Key == "" {
+			continue
+		}
+		orderLine.Quantity, err = parseOrderNumber(field("quantity"), format.DecimalComma)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid quantity %q", line, field("quantity"))
+		}
+		if price := field("price"); price != "" {
+			orderLine.Price.Float64, err = parseOrderNumber(price, format.DecimalComma)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid price %q", line, price)
+			}
+			orderLine.Price.Valid = true
+		}
+		lines = append(lines, orderLine)
+	}
+
+	if columns == nil {
+		return nil, errors.New("order has no key column")
+	}
+	return lines, nil
+}
+
+// orderColumns returns the columns of the fields, if record is the header
+func orderColumns(record []string, format *OrderFormat) map[string]int {
+	fields := map[string][]string{
+		"key":         format.Key,
+		"quantity":    format.Quantity,
+		"price":       format.Price,
+		"description": format.Description,
+	}
+
+	columns := make(map[string]int)
+	for n, cell := range record {
+		cell = strings.ToLower(strings.TrimSpace(cell))
+		for field, headers := range fields {
+			if _, ok := columns[field]; ok {
+				continue
+			}
+			for _, header := range headers {
+				if cell == header {
+					columns[field] = n
+				}
+			}
+		}
+	}
+
+	if _, ok := columns["key"]; !ok {
+		return nil
+	}
+	return columns
+}
+
+// parseOrderNumber parses numbers with thousands separators and an optional
+// currency, like "1.234,50 €" with decimal comma or "1,234.50"
+func parseOrderNumber(s string, decimalComma bool) (float64, error) {
+	s = strings.TrimSpace(strings.TrimRight(s, " €EUR"))
+	s = strings.Replace(s, " ", "", -1)
+	if decimalComma {
+		s = strings.Replace(s, ".", "", -1)
+		s = strings.Replace(s, ",", ".", -1)
+	} else {
+		s = strings.Replace(s, ",", "", -1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// Actions of an OrderRow
+const (
+	OrderRestock = "restock"
+	OrderNew     = "new"
+)
+
+// An OrderRow describes what happens to an order line. Lines which match a
+// distributor part restock its part, the others are offered as new parts.
+type OrderRow struct {
+	OrderLine
+	Action        string
+	DistributorId int64
+	PartId        int64
+	Name          string
+	StockUnit     sql.NullString
+	Before        float64
+	After         float64
+	// OldPrice is the price of the distributor part before the import
+	OldPrice float64
+}
+
+// PriceChanged tells whether the price of the distributor part is updated
+func (r *OrderRow) PriceChanged() bool {
+	return r.Action == OrderRestock && r.Price.Valid && r.Price.Float64 != r.OldPrice
+}
+
+// NewPartURL returns the URL of the new part form, prefilled with the order
+// line
+func (r *OrderRow) NewPartURL() string {
+	values := url.Values{
+		"name":        {r.Description},
+		"description": {r.Description},
+		"amount":      {formatAmount(r.Quantity)},
+		"distributor": {strconv.FormatInt(r.DistributorId, 10)},
+		"key":         {r.Key},
+	}
+	if r.Description == "" {
+		values.Set("name", r.Key)
+	}
+	if r.Price.Valid {
+		values.Set("price", strconv.FormatFloat(r.Price.Float64, 'f', -1, 64))
+	}
+	return "/parts/new?" + values.Encode()
+}
+
+// An OrderResult summarises the import of an order
+type OrderResult struct {
+	Distributor *Distributor
+	Rows        []OrderRow
+	Restocked   int
+	New         int
+}
+
+// OrderDistributor returns the distributor of the order format
+func OrderDistributor(q sqlx.Queryer, format *OrderFormat) (*Distributor, error) {
+	distributor := new(Distributor)
+	err := sqlx.Get(q, distributor, `SELECT * FROM 'distributor' WHERE "name" = ?`,
+		format.Distributor)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("unknown distributor %q", format.Distributor)
+	}
+	return distributor, err
+}
+
+// OrderKeys maps the keys of order lines to the keys which the API of the
+// distributor resolved them to
+type OrderKeys map[string]string
+
+// ParseOrderKeys reads keys encoded by OrderKeys.String
+func ParseOrderKeys(s string) (OrderKeys, error) {
+	values, err := url.ParseQuery(s)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(OrderKeys)
+	for key, res := range values {
+		keys[key] = res[0]
+	}
+	return keys, nil
+}
+
+// String encodes the keys like a query string, so they can be carried in a
+// form
+func (k OrderKeys) String() string {
+	values := url.Values{}
+	for key, res := range k {
+		values.Set(key, res)
+	}
+	return values.Encode()
+}
+
+// Apply replaces the keys of the lines by the resolved ones
+func (k OrderKeys) Apply(lines []OrderLine) {
+	for n := range lines {
+		if res, ok := k[lines[n].Key]; ok {
+			lines[n].Key = res
+		}
+	}
+}
+
+// ResolveOrderKeys resolves the keys which match no distributor part by the
+// API of the distributor. The API makes requests to the distributor, so it is
+// called outside of a transaction, once per key. Keys which the API does not
+// know are kept.
+func ResolveOrderKeys(q sqlx.Queryer, distributor *Distributor, lines []OrderLine) (OrderKeys, error) {
+	keys := make(OrderKeys)
+	if !distributor.Api.Valid || APIs[distributor.Api.String] == nil {
+		return keys, nil
+	}
+	api := APIs[distributor.Api.String]
+
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if seen[line.Key] {
+			continue
+		}
+		seen[line.Key] = true
+
+		distributorPart, err := matchOrderLine(q, distributor.Id, line.Key)
+		if err != nil {
+			return nil, err
+		} else if distributorPart != nil {
+			continue
+		}
+		// The Reichelt API returns 0 if no article matches
+		if res := api(line.Key); res != "" && res != "0" && res != line.Key {
+			keys[line.Key] = res
+		}
+	}
+	return keys, nil
+}
+
+// ImportOrder restocks the parts of an order, whose lines are matched by the
+// distributor keys, and updates their prices. The keys should be resolved by
+// ResolveOrderKeys and applied before. Everything happens within tx, the caller commits
+// it or rolls it back for a dry-run.
+func ImportOrder(tx *sqlx.Tx, distributor *Distributor, lines []OrderLine) (*OrderResult, error) {
+	result := &OrderResult{
+		Distributor: distributor,
+	}
+
+	for _, line := range lines {
+		row := OrderRow{
+			OrderLine:     line,
+			DistributorId: distributor.Id,
+		}
+		if err := importOrderLine(tx, &row); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line.Line, err)
+		}
+		switch row.Action {
+		case OrderRestock:
+			result.Restocked++
+		case OrderNew:
+			result.New++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	return result, nil
+}
+
+// matchOrderLine returns the distributor part of the key, or nil
+func matchOrderLine(q sqlx.Queryer, distributorId int64, key string) (*DistributorPart, error) {
+	distributorPart := new(DistributorPart)
+	err := sqlx.Get(q, distributorPart, `SELECT * FROM 'distributor_part'
+	WHERE "distributor_id" = ? AND "key" = ? ORDER BY "id" ASC LIMIT 1`,
+		distributorId, key)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return distributorPart, err
+}
+
+func importOrderLine(tx *sqlx.Tx, row *OrderRow) error {
+	distributorPart, err := matchOrderLine(tx, row.DistributorId, row.Key)
+	if err != nil {
+		return err
+	}
+
+	if distributorPart == nil {
+		row.Action = OrderNew
+		return nil
+	}
+	row.Action = OrderRestock
+	row.PartId = distributorPart.PartId
+	row.OldPrice = distributorPart.Price
+
+	part := new(Part)
+	err = tx.Get(part, `SELECT * FROM 'part' WHERE "id" = ?`, distributorPart.PartId)
+	if err != nil {
+		return err
+	}
+	row.Name, row.StockUnit = part.Name, part.StockUnit
+
+	latest, err := part.LatestAmount(tx)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		row.Before = latest.Amount
+	}
+	row.After = row.Before + row.Quantity
+
+	partAmount := &PartAmount{
+		PartId:    part.Id,
+		Amount:    row.After,
+		Timestamp: time.Now(),
+	}
+	if err := partAmount.Save(tx); err != nil {
+		return err
+	}
+
+	if row.PriceChanged() {
+		distributorPart.Price = row.Price.Float64
+		return distributorPart.Save(tx)
+	}
+	return nil
+}
+
+// ImportOrderHandler shows the order import form, and restocks the parts of
+// the uploaded order. Like ImportHandler, a dry-run shows a preview which
+// carries the order data.
+func (app *Application) ImportOrderHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		app.renderTemplate(w, r, map[string]interface{}{
+			"Formats": orderFormatNames(),
+			"Form":    url.Values{},
+		}, "ImportOrder", "Layout")
+		return
+	}
+
+	data := r.FormValue("data")
+	file, _, err := r.FormFile("file")
+	uploaded := err == nil
+	if uploaded {
+		defer file.Close()
+		buf, err := ioutil.ReadAll(file)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+		data = string(buf)
+	}
+
+	format, ok := OrderFormats[r.FormValue("format")]
+	if !ok {
+		app.Error(w, fmt.Errorf("unknown order format %q", r.FormValue("format")))
+		return
+	}
+
+	lines, err := ParseOrder(strings.NewReader(data), format)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	distributor, err := OrderDistributor(app.DB, format)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	// The keys are resolved for the preview, and carried in the form to
+	// import the previewed file
+	keys, err := ParseOrderKeys(r.FormValue("keys"))
+	if _, ok := r.Form["keys"]; err == nil && (uploaded || !ok) {
+		keys, err = ResolveOrderKeys(app.DB, distributor, lines)
+	}
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	keys.Apply(lines)
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	result, err := ImportOrder(tx, distributor, lines)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	dryRun := r.FormValue("dry_run") != ""
+	if !dryRun {
+		tx.Commit()
+	}
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"Formats":   orderFormatNames(),
+		"Form":      r.Form,
+		"CSV":       data,
+		"Keys":      keys,
+		"Result":    result,
+		"Committed": !dryRun,
+	}, "ImportOrder", "Layout")
+}
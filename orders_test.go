@@ -0,0 +1,129 @@
+package inventory
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOrderNumber(t *testing.T) {
+	type testCase struct {
+		Input        string
+		DecimalComma bool
+		Result       float64
+		Err          bool
+	}
+
+	testCases := []testCase{
+		testCase{"12", false, 12, false},
+		testCase{"0.123", false, 0.123, false},
+		testCase{"1,234.50", false, 1234.5, false},
+		testCase{"0,123", true, 0.123, false},
+		testCase{"1,5", true, 1.5, false},
+		testCase{"1.234,50 €", true, 1234.5, false},
+		testCase{"12,30 EUR", true, 12.3, false},
+		testCase{"", true, 0, true},
+		testCase{"ten", false, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := parseOrderNumber(testCase.Input, testCase.DecimalComma)
+		if (err != nil) != testCase.Err {
+			t.Errorf("Got error %v", err)
+		} else if res != testCase.Result {
+			t.Errorf("Got %v, wants %v", res, testCase.Result)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	type testCase struct {
+		Format string
+		Input  string
+		Result []OrderLine
+		Err    bool
+	}
+
+	price := func(p float64) sql.NullFloat64 {
+		return sql.NullFloat64{Float64: p, Valid: true}
+	}
+
+	testCases := []testCase{
+		testCase{"reichelt", "Bestellung 123\n" +
+			"Artikelnr.;Bezeichnung;Menge;Einzelpreis\n" +
+			"1/4W 4,7K;Widerstand 4,7 kOhm;100;0,10 €\n" +
+			";Summe;;10,00 €\n",
+			[]OrderLine{{3, "1/4W 4,7K", 100, price(0.1), "Widerstand 4,7 kOhm"}}, false},
+		testCase{"farnell-de", "Bestellnummer;Menge;Stückpreis;Beschreibung\n" +
+			"1469854;1,5;0,123;Kabel\n" +
+			"2112948;1.000;1.234,50;\n",
+			[]OrderLine{
+				{2, "1469854", 1.5, price(0.123), "Kabel"},
+				{3, "2112948", 1000, price(1234.5), ""},
+			}, false},
+		testCase{"farnell", "Order Code,Quantity,Unit Price,Description\n" +
+			"1469854,\"1,000\",0.123,Cable\n" +
+			"2112948,5,,\n",
+			[]OrderLine{
+				{2, "1469854", 1000, price(0.123), "Cable"},
+				{3, "2112948", 5, sql.NullFloat64{}, ""},
+			}, false},
+		testCase{"farnell", "Order Code,Unit Price\n1469854,0.123\n", nil, true},
+		testCase{"farnell", "Quantity,Unit Price\n5,0.123\n", nil, true},
+		testCase{"pollin", "Bestell-Nr.;Menge\n810123;viele\n", nil, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseOrder(strings.NewReader(testCase.Input), OrderFormats[testCase.Format])
+		if (err != nil) != testCase.Err {
+			t.Errorf("Got error %v", err)
+		} else if err == nil && !reflect.DeepEqual(res, testCase.Result) {
+			t.Errorf("Got %v, wants %v", res, testCase.Result)
+		}
+	}
+}
+
+func TestOrderKeys(t *testing.T) {
+	type testCase struct {
+		Keys   OrderKeys
+		Input  []string
+		Result []string
+	}
+
+	testCases := []testCase{
+		testCase{OrderKeys{}, []string{"METALL 10K"}, []string{"METALL 10K"}},
+		testCase{OrderKeys{"METALL 10K": "1234"}, []string{"METALL 10K", "1N 4148"},
+			[]string{"1234", "1N 4148"}},
+		testCase{OrderKeys{"a&b=c": "42", "x y": "7"}, []string{"x y", "a&b=c"},
+			[]string{"7", "42"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		keys, err := ParseOrderKeys(testCase.Keys.String())
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if !reflect.DeepEqual(keys, testCase.Keys) {
+			t.Errorf("Got %v", keys)
+		}
+
+		lines := []OrderLine{}
+		for _, key := range testCase.Input {
+			lines = append(lines, OrderLine{Key: key})
+		}
+		keys.Apply(lines)
+		for n, line := range lines {
+			if line.Key != testCase.Result[n] {
+				t.Errorf("Got key %q, wants %q", line.Key, testCase.Result[n])
+			}
+		}
+	}
+}
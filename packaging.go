@@ -0,0 +1,195 @@
+package inventory
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const packageEpsilon = 1e-9
+
+// packagings returns the packagings of a part, largest first
+func packagings(db sqlx.Queryer, partId int64) ([]Packaging, error) {
+	res := []Packaging{}
+	err := sqlx.Select(db, &res, `SELECT * FROM 'packaging' WHERE "part_id" = ?
+	ORDER BY "quantity" DESC, "name" ASC`, partId)
+	return res, err
+}
+
+// packageCount is a number of whole packages of one packaging
+type packageCount struct {
+	Packaging
+	Count int64
+}
+
+func (c packageCount) String() string {
+	if c.Count == 1 {
+		return "1 " + c.Name
+	}
+	return fmt.Sprintf("%d %ss", c.Count, c.Name)
+}
+
+// splitPackages splits an amount into whole packages, beginning with the
+// largest packaging, and the loose rest
+func splitPackages(amount float64, packagings []Packaging) ([]packageCount, float64) {
+	counts := []packageCount{}
+	for _, packaging := range packagings {
+		if packaging.Quantity <= 0 {
+			continue
+		}
+		// The epsilon compensates rounding errors of the division
+		count := int64(amount/packaging.Quantity + packageEpsilon)
+		if count <= 0 {
+			continue
+		}
+		counts = append(counts, packageCount{packaging, count})
+		amount -= float64(count) * packaging.Quantity
+		if math.Abs(amount) < packageEpsilon*packaging.Quantity {
+			amount = 0
+		}
+	}
+	return counts, amount
+}
+
+// formatPackaged formats an amount in whole packages and the loose rest, e.g.
+// "3 reels + 1200 pcs"
+func formatPackaged(amount float64, unit sql.NullString, packagings []Packaging) string {
+	counts, rest := splitPackages(amount, packagings)
+	items := make([]string, 0, len(counts)+1)
+	for _, count := range counts {
+		items = append(items, count.String())
+	}
+	if rest != 0 || len(items) == 0 {
+		items = append(items, formatAmount(rest)+" "+stockUnit(unit))
+	}
+	return strings.Join(items, " + ")
+}
+
+// reorder is the quantity which has to be ordered to get at least Need, rounded
+// up to whole packages
+type reorder struct {
+	Need float64
+	packageCount
+}
+
+// Quantity returns the ordered amount
+func (r *reorder) Quantity() float64 {
+	return float64(r.Count) * r.Packaging.Quantity
+}
+
+// roundUpToPackages rounds an amount up to whole packages. The packaging with
+// the least surplus is chosen, on ties the larger packaging. It returns nil if
+// there are no packagings.
+func roundUpToPackages(need float64, packagings []Packaging) *reorder {
+	var best *reorder
+	for _, packaging := range packagings {
+		if packaging.Quantity <= 0 {
+			continue
+		}
+		count := int64(math.Ceil(need/packaging.Quantity - packageEpsilon))
+		r := &reorder{need, packageCount{packaging, count}}
+		if best == nil || r.Quantity() < best.Quantity() {
+			best = r
+		}
+	}
+	return best
+}
+
+// packagedAmount multiplies amount with the quantity of the packaging given by
+// its id, which has to belong to the part
+func packagedAmount(db sqlx.Queryer, part *Part, amount float64, id string) (float64, error) {
+	packaging := new(Packaging)
+	err := sqlx.Get(db, packaging, `SELECT * FROM 'packaging'
+	WHERE "id" = ? AND "part_id" = ?`, id, part.Id)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("unknown packaging")
+	} else if err != nil {
+		return 0, err
+	}
+	return amount * packaging.Quantity, nil
+}
+
+func (app *Application) CreatePackagingHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	id, _ := strconv.Atoi(path.Base(r.URL.Path))
+	if id == 0 {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	part := new(Part)
+	err = tx.Get(part, `SELECT * FROM 'part' WHERE "id" = ?`, id)
+	if app.SQLError(w, r, err) {
+		return
+	}
+
+	packaging := &Packaging{
+		PartId: part.Id,
+	}
+
+	err = packaging.LoadForm(r.PostForm)
+	if err == nil {
+		packaging.Quantity, err = ParseAmount(r.PostForm.Get("quantity"),
+			stockUnit(part.StockUnit))
+	}
+	if err == nil && (packaging.Name == "" || packaging.Quantity <= 0) {
+		err = errors.New("packaging needs a name and a positive quantity")
+	}
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	err = packaging.Save(tx)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx.Commit()
+
+	http.Redirect(w, r, fmt.Sprintf("/parts/edit/%d", part.Id), http.StatusSeeOther)
+}
+
+func (app *Application) DeletePackagingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := path.Base(r.URL.Path)
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	packaging := new(Packaging)
+	err := tx.Get(packaging, `SELECT * FROM 'packaging' WHERE "id" = ?`, id)
+	if app.SQLError(w, r, err) {
+		return
+	}
+
+	_, err = tx.Exec(`DELETE FROM 'packaging' WHERE "id" = ?`, id)
+	if app.SQLError(w, r, err) {
+		return
+	}
+
+	tx.Commit()
+
+	http.Redirect(w, r, fmt.Sprintf("/parts/edit/%d", packaging.PartId), http.StatusSeeOther)
+}
@@ -0,0 +1,108 @@
+package inventory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testPackagings = []Packaging{
+	Packaging{Name: "reel", Quantity: 1000},
+	Packaging{Name: "tape", Quantity: 100},
+	Packaging{Name: "bag", Quantity: 50},
+}
+
+func TestSplitPackages(t *testing.T) {
+	type testCase struct {
+		Amount float64
+		Counts []int64
+		Rest   float64
+	}
+
+	testCases := []testCase{
+		testCase{0, []int64{}, 0},
+		testCase{1000, []int64{1}, 0},
+		testCase{3000, []int64{3}, 0},
+		testCase{2150, []int64{2, 1, 1}, 0},
+		testCase{1170, []int64{1, 1, 1}, 20},
+		testCase{30, []int64{}, 30},
+		testCase{0.3, []int64{}, 0.3},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		counts, rest := splitPackages(testCase.Amount, testPackagings)
+		if len(counts) != len(testCase.Counts) {
+			t.Errorf("Got %v", counts)
+			continue
+		}
+		for n, count := range counts {
+			if count.Count != testCase.Counts[n] {
+				t.Errorf("Got %v", counts)
+			}
+		}
+		if rest != testCase.Rest {
+			t.Errorf("Got rest %v", rest)
+		}
+	}
+
+	counts, rest := splitPackages(0.3, []Packaging{Packaging{Name: "spool", Quantity: 0.1}})
+	if len(counts) != 1 || counts[0].Count != 3 || rest != 0 {
+		t.Errorf("Got %v and rest %v for 0.3 in spools of 0.1", counts, rest)
+	}
+}
+
+func TestRoundUpToPackages(t *testing.T) {
+	type testCase struct {
+		Need      float64
+		Packaging string
+		Count     int64
+		Quantity  float64
+	}
+
+	testCases := []testCase{
+		testCase{0, "reel", 0, 0},
+		testCase{1000, "reel", 1, 1000},
+		testCase{2000, "reel", 2, 2000},
+		testCase{120, "bag", 3, 150},
+		testCase{980, "reel", 1, 1000},
+		testCase{200, "tape", 2, 200},
+		testCase{1, "bag", 1, 50},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		r := roundUpToPackages(testCase.Need, testPackagings)
+		if r == nil {
+			t.Error("Got nil")
+			continue
+		}
+		if r.Name != testCase.Packaging || r.Count != testCase.Count ||
+			r.Quantity() != testCase.Quantity || r.Need != testCase.Need {
+			t.Errorf("Got %d %s", r.Count, r.Name)
+		}
+	}
+
+	if r := roundUpToPackages(10, nil); r != nil {
+		t.Errorf("Got %v without packagings", r)
+	}
+	if r := roundUpToPackages(10, []Packaging{Packaging{Name: "box"}}); r != nil {
+		t.Errorf("Got %v for packaging without quantity", r)
+	}
+}
+
+func TestDeletePackagingHandlerMethod(t *testing.T) {
+	app := NewApplication()
+	for _, method := range []string{"GET", "HEAD"} {
+		t.Log(method)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/parts/packagings/delete/1", nil)
+		app.DeletePackagingHandler(w, r)
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != "POST" {
+			t.Errorf("Got status %d", w.Code)
+		}
+	}
+}
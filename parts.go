@@ -2,6 +2,7 @@ package inventory
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"html/template"
 	"net/http"
@@ -11,9 +12,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fritz0705/inventory/labels"
 	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
 )
 
+// PartsPerPage is the default page size of the parts list
 var PartsPerPage = 10
 
 type siRange struct {
@@ -52,18 +56,51 @@ func (s siRange) String() string {
 	return s.Low.String() + "-" + s.High.String()
 }
 
+// DateFormat is the format of dates in forms and URLs
+const DateFormat = "2006-01-02"
+
 type partsFilter struct {
-	Categories map[int64]bool
-	Places     map[int64]bool
-	Value      *siRange
-	Name       string
-	Stock      *siRange
+	Categories    map[int64]bool
+	Places        map[int64]bool
+	Value         *siRange
+	Tolerance     *si.Tolerance
+	Name          string
+	Stock         *siRange
+	Tag           string
+	Description   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Distributors  map[int64]bool
+	NoDistributor map[int64]bool
+	Price         *siRange
+	// HasImage and HasDatasheet are either "yes", "no" or empty
+	HasImage     string
+	HasDatasheet string
+}
+
+func loadIds(ids map[int64]bool, value []string) {
+	for _, val := range value {
+		id, _ := strconv.Atoi(val)
+		if id != 0 {
+			ids[int64(id)] = true
+		}
+	}
+}
+
+func loadYesNo(val string) (string, error) {
+	switch val {
+	case "yes", "no":
+		return val, nil
+	}
+	return "", fmt.Errorf("invalid value %q, expected yes or no", val)
 }
 
 func loadPartsFilter(form url.Values) (filter *partsFilter, err error) {
 	filter = &partsFilter{
-		Categories: make(map[int64]bool),
-		Places:     make(map[int64]bool),
+		Categories:    make(map[int64]bool),
+		Places:        make(map[int64]bool),
+		Distributors:  make(map[int64]bool),
+		NoDistributor: make(map[int64]bool),
 	}
 	for key, value := range form {
 		val := value[0]
@@ -73,24 +110,36 @@ func loadPartsFilter(form url.Values) (filter *partsFilter, err error) {
 		switch key {
 		case "value":
 			filter.Value, err = parseSiRange(val)
+		case "tolerance":
+			var tolerance si.Tolerance
+			tolerance, err = si.ParseTolerance(val)
+			filter.Tolerance = &tolerance
 		case "amount":
 			filter.Stock, err = parseSiRange(val)
+		case "price":
+			filter.Price, err = parseSiRange(val)
 		case "name":
 			filter.Name = val
+		case "tag":
+			filter.Tag = val
+		case "description":
+			filter.Description = val
+		case "created_after":
+			filter.CreatedAfter, err = time.ParseInLocation(DateFormat, val, time.Local)
+		case "created_before":
+			filter.CreatedBefore, err = time.ParseInLocation(DateFormat, val, time.Local)
+		case "image":
+			filter.HasImage, err = loadYesNo(val)
+		case "datasheet":
+			filter.HasDatasheet, err = loadYesNo(val)
 		case "category":
-			for _, val := range value {
-				category, _ := strconv.Atoi(val)
-				if category != 0 {
-					filter.Categories[int64(category)] = true
-				}
-			}
+			loadIds(filter.Categories, value)
 		case "place":
-			for _, val := range value {
-				place, _ := strconv.Atoi(val)
-				if place != 0 {
-					filter.Places[int64(place)] = true
-				}
-			}
+			loadIds(filter.Places, value)
+		case "distributor":
+			loadIds(filter.Distributors, value)
+		case "no_distributor":
+			loadIds(filter.NoDistributor, value)
 		}
 		if err != nil {
 			return
@@ -99,91 +148,303 @@ func loadPartsFilter(form url.Values) (filter *partsFilter, err error) {
 	return
 }
 
-func (f partsFilter) CategoriesList() []string {
-	res := make([]string, len(f.Categories))
+func idList(ids map[int64]bool) []string {
+	res := make([]string, len(ids))
 	n := 0
-	for id := range f.Categories {
+	for id := range ids {
 		res[n] = strconv.Itoa(int(id))
 		n++
 	}
 	return res
 }
 
+func (f partsFilter) CategoriesList() []string {
+	return idList(f.Categories)
+}
+
 func (f partsFilter) PlacesList() []string {
-	res := make([]string, len(f.Places))
-	n := 0
-	for id := range f.Places {
-		res[n] = strconv.Itoa(int(id))
-		n++
+	return idList(f.Places)
+}
+
+// rangeSQL returns a condition which matches if the expression lies within
+// the range
+func rangeSQL(expr string, r *siRange) (string, []interface{}) {
+	if r.IsEmpty() {
+		return expr + ` = ?`, []interface{}{r.Low.Value()}
 	}
-	return res
+	return expr + ` BETWEEN ? AND ?`, []interface{}{r.Low.Value(), r.High.Value()}
 }
 
-func buildListPartsQuery(filter *partsFilter, form url.Values) (query string, args []interface{}, err error) {
-	query += `SELECT * FROM 'part_view' WHERE (1=1)`
+// SQL returns a condition for the WHERE clause of a query on part_view and
+// its arguments
+func (f *partsFilter) SQL() (query string, args []interface{}) {
+	query += `(1=1)`
 
-	lastId, _ := strconv.Atoi(form.Get("last_id"))
-	firstId, _ := strconv.Atoi(form.Get("first_id"))
+	if len(f.Categories) != 0 {
+		query += ` AND "category_id" IN (` + strings.Join(f.CategoriesList(), ", ") + `)`
+	}
 
-	if lastId != 0 {
-		query += ` AND "id" > ?`
-		args = append(args, lastId)
-	} else if firstId != 0 {
-		query += ` AND "id" < ?`
-		args = append(args, firstId)
+	if len(f.Places) != 0 {
+		query += ` AND "place_id" IN (` + strings.Join(f.PlacesList(), ", ") + `)`
 	}
 
-	if len(filter.Categories) != 0 {
-		query += ` AND "category_id" IN (` + strings.Join(filter.CategoriesList(), ", ") + `)`
+	if f.Value != nil && f.Tolerance != nil {
+		// Parts satisfy the requirement if their whole tolerance band lies
+		// within the requested one
+		low := f.Tolerance.Band(f.Value.Low).Low
+		high := f.Tolerance.Band(f.Value.High).High
+		query += ` AND "value_min" >= ? AND "value_max" <= ?`
+		args = append(args, low, high)
+	} else if f.Value != nil && f.Value.IsEmpty() {
+		query += ` AND "value_decimal" = ?`
+		args = append(args, f.Value.Low.Decimal())
+	} else if f.Value != nil {
+		cond, condArgs := rangeSQL(`"value"`, f.Value)
+		query += ` AND ` + cond
+		args = append(args, condArgs...)
 	}
 
-	if len(filter.Places) != 0 {
-		query += ` AND "place_id" IN (` + strings.Join(filter.PlacesList(), ", ") + `)`
+	if f.Stock != nil {
+		cond, condArgs := rangeSQL(`"amount"`, f.Stock)
+		query += ` AND ` + cond
+		args = append(args, condArgs...)
 	}
 
-	if filter.Value != nil {
-		if filter.Value.IsEmpty() {
-			query += ` AND "value" = ?`
-			args = append(args, filter.Value.Low.Value())
-		} else {
-			query += ` AND "value" BETWEEN ? AND ?`
-			args = append(args, filter.Value.Low.Value(), filter.Value.High.Value())
-		}
+	if f.Name != "" {
+		query += ` AND "name" GLOB ?`
+		args = append(args, f.Name)
 	}
 
-	if filter.Stock != nil {
-		if filter.Stock.IsEmpty() {
-			query += ` AND "amount" = ?`
-			args = append(args, filter.Stock.Low.Value())
-		} else {
-			query += ` AND "value" BETWEEN ? AND ?`
-			args = append(args, filter.Stock.Low.Value(), filter.Stock.High.Value())
-		}
+	if f.Tag != "" {
+		query += ` AND "id" IN (SELECT "part_id" FROM 'part_tag'
+		JOIN 'tag' ON 'tag'."id" = "tag_id" WHERE 'tag'."name" = ?)`
+		args = append(args, f.Tag)
 	}
 
-	if filter.Name != "" {
-		query += ` AND "name" GLOB ?`
-		args = append(args, filter.Name)
+	if f.Description != "" {
+		query += ` AND "description" LIKE ?`
+		args = append(args, "%"+f.Description+"%")
 	}
 
-	query += ` ORDER BY "id" DESC LIMIT ` + strconv.Itoa(PartsPerPage)
-	if form["page"] != nil {
-		page, _ := strconv.Atoi(form.Get("page"))
-		if page != 0 {
-			query += ` OFFSET ?`
-			args = append(args, page*PartsPerPage)
-		}
+	if !f.CreatedAfter.IsZero() {
+		query += ` AND "created_at" >= ?`
+		args = append(args, f.CreatedAfter)
+	}
+
+	if !f.CreatedBefore.IsZero() {
+		// The date is inclusive
+		query += ` AND "created_at" < ?`
+		args = append(args, f.CreatedBefore.AddDate(0, 0, 1))
+	}
+
+	if len(f.Distributors) != 0 {
+		query += ` AND "id" IN (SELECT "part_id" FROM 'distributor_part'
+		WHERE "distributor_id" IN (` + strings.Join(idList(f.Distributors), ", ") + `))`
+	}
+
+	if len(f.NoDistributor) != 0 {
+		query += ` AND "id" NOT IN (SELECT "part_id" FROM 'distributor_part'
+		WHERE "distributor_id" IN (` + strings.Join(idList(f.NoDistributor), ", ") + `))`
+	}
+
+	if f.Price != nil {
+		cond, condArgs := rangeSQL(`"price"`, f.Price)
+		query += ` AND "id" IN (SELECT "part_id" FROM 'distributor_part' WHERE ` +
+			cond + `)`
+		args = append(args, condArgs...)
+	}
+
+	switch f.HasImage {
+	case "yes":
+		query += ` AND "image_id" IS NOT NULL`
+	case "no":
+		query += ` AND "image_id" IS NULL`
+	}
+
+	// Datasheets are recognized as PDF attachments
+	datasheets := `SELECT "part_id" FROM 'attachment' WHERE "type" LIKE 'application/pdf%'`
+	switch f.HasDatasheet {
+	case "yes":
+		query += ` AND "id" IN (` + datasheets + `)`
+	case "no":
+		query += ` AND "id" NOT IN (` + datasheets + `)`
 	}
 
 	return
 }
 
-func pageQuerys(url *url.URL, cur int) (string, string) {
-	values := url.Query()
-	values.Set("page", strconv.Itoa(cur-1))
-	prev := values.Encode()
-	values.Set("page", strconv.Itoa(cur+1))
-	return prev, values.Encode()
+// Values returns the form representation of a filter, which is understood by
+// loadPartsFilter
+func (f *partsFilter) Values() url.Values {
+	values := make(url.Values)
+	for _, id := range f.CategoriesList() {
+		values.Add("category", id)
+	}
+	for _, id := range f.PlacesList() {
+		values.Add("place", id)
+	}
+	if f.Value != nil {
+		values.Set("value", f.Value.String())
+	}
+	if f.Tolerance != nil {
+		values.Set("tolerance", f.Tolerance.String())
+	}
+	if f.Stock != nil {
+		values.Set("amount", f.Stock.String())
+	}
+	if f.Name != "" {
+		values.Set("name", f.Name)
+	}
+	if f.Tag != "" {
+		values.Set("tag", f.Tag)
+	}
+	if f.Description != "" {
+		values.Set("description", f.Description)
+	}
+	if !f.CreatedAfter.IsZero() {
+		values.Set("created_after", f.CreatedAfter.Format(DateFormat))
+	}
+	if !f.CreatedBefore.IsZero() {
+		values.Set("created_before", f.CreatedBefore.Format(DateFormat))
+	}
+	for _, id := range idList(f.Distributors) {
+		values.Add("distributor", id)
+	}
+	for _, id := range idList(f.NoDistributor) {
+		values.Add("no_distributor", id)
+	}
+	if f.Price != nil {
+		values.Set("price", f.Price.String())
+	}
+	if f.HasImage != "" {
+		values.Set("image", f.HasImage)
+	}
+	if f.HasDatasheet != "" {
+		values.Set("datasheet", f.HasDatasheet)
+	}
+	return values
+}
+
+// partsSortKeys maps the sort keys of the parts list to expressions on
+// part_view. NULLs are replaced, so every expression is totally ordered, which
+// is required for keyset pagination.
+var partsSortKeys = map[string]string{
+	"id":       `"id"`,
+	"name":     `"name"`,
+	"value":    `IFNULL("value", -1e308)`,
+	"stock":    `IFNULL("amount", 0)`,
+	"place":    `IFNULL("place_name", '')`,
+	"category": `IFNULL("category_name", '')`,
+	"created":  `IFNULL("created_at", '')`,
+}
+
+// PageSizes are the selectable page sizes of the parts list
+var PageSizes = []int{10, 25, 50, 100}
+
+// partsPage describes the order and the window of the parts list.
+//
+// Pages are addressed either by the id of the last part of the previous page
+// (After), by the id of the first part of the next page (Before) or by the
+// page number. An anchor always takes precedence over the page number, which
+// then only serves as label. Anchors are stable when parts are created or
+// deleted, page numbers are not.
+type partsPage struct {
+	Sort    string
+	Desc    bool
+	PerPage int
+	Page    int
+	After   int64
+	Before  int64
+}
+
+func loadPartsPage(form url.Values) *partsPage {
+	p := &partsPage{
+		Sort:    form.Get("sort"),
+		Desc:    form.Get("order") == "desc",
+		PerPage: PartsPerPage,
+		Page:    1,
+	}
+
+	if _, ok := partsSortKeys[p.Sort]; !ok {
+		p.Sort = "id"
+		p.Desc = form.Get("order") != "asc"
+	}
+
+	perPage, _ := strconv.Atoi(form.Get("per_page"))
+	for _, size := range PageSizes {
+		if perPage == size {
+			p.PerPage = perPage
+		}
+	}
+
+	if page, _ := strconv.Atoi(form.Get("page")); page > 0 {
+		p.Page = page
+	}
+
+	p.After, _ = strconv.ParseInt(form.Get("after"), 10, 64)
+	if p.After == 0 {
+		p.Before, _ = strconv.ParseInt(form.Get("before"), 10, 64)
+	}
+
+	return p
+}
+
+// Values returns the form representation of the order and page size
+func (p *partsPage) Values() url.Values {
+	values := make(url.Values)
+	if p.Sort != "id" {
+		values.Set("sort", p.Sort)
+	}
+	if p.Desc {
+		values.Set("order", "desc")
+	} else {
+		values.Set("order", "asc")
+	}
+	if p.PerPage != PartsPerPage {
+		values.Set("per_page", strconv.Itoa(p.PerPage))
+	}
+	return values
+}
+
+// Reversed reports whether the query returns the page in reversed order, which
+// is the case when paging backwards from an anchor
+func (p *partsPage) Reversed() bool {
+	return p.Before != 0
+}
+
+// SQL extends a condition on part_view by the ORDER BY and LIMIT clauses of
+// the page. One part more than requested is selected, so the caller can tell
+// whether there is a following page.
+func (p *partsPage) SQL(where string, args []interface{}) (query string, _ []interface{}) {
+	key := partsSortKeys[p.Sort]
+
+	desc := p.Desc
+	if p.Reversed() {
+		desc = !desc
+	}
+	dir, cmp := "ASC", ">"
+	if desc {
+		dir, cmp = "DESC", "<"
+	}
+
+	query += `SELECT * FROM 'part_view' WHERE ` + where
+
+	if anchor := p.After + p.Before; anchor != 0 {
+		anchorKey := `(SELECT ` + key + ` FROM 'part_view' WHERE "id" = ?)`
+		query += ` AND (` + key + ` ` + cmp + ` ` + anchorKey + ` OR (` + key +
+			` = ` + anchorKey + ` AND "id" ` + cmp + ` ?))`
+		args = append(args, anchor, anchor, anchor)
+	}
+
+	query += ` ORDER BY ` + key + ` ` + dir + `, "id" ` + dir
+	query += ` LIMIT ` + strconv.Itoa(p.PerPage+1)
+
+	if p.After == 0 && p.Before == 0 && p.Page > 1 {
+		query += ` OFFSET ?`
+		args = append(args, (p.Page-1)*p.PerPage)
+	}
+
+	return query, args
 }
 
 func (app *Application) ListPartsHandler(w http.ResponseWriter, r *http.Request) {
@@ -204,15 +465,23 @@ func (app *Application) ListPartsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	page := loadPartsPage(r.Form)
+
 	tx := app.DB.MustBegin()
 	defer tx.Rollback()
 
-	query, args, err := buildListPartsQuery(filter, r.Form)
+	where, args := filter.SQL()
+
+	var total int
+	err = tx.QueryRowx(`SELECT COUNT(*) FROM 'part_view' WHERE `+where,
+		args...).Scan(&total)
 	if err != nil {
 		app.Error(w, err)
 		return
 	}
 
+	query, args := page.SQL(where, args)
+
 	partViews := []PartView{}
 	err = tx.Select(&partViews, query, args...)
 	if err != nil {
@@ -220,6 +489,38 @@ func (app *Application) ListPartsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The query selects one part more than needed to detect further pages
+	more := len(partViews) > page.PerPage
+	if more {
+		partViews = partViews[:page.PerPage]
+	}
+	if page.Reversed() {
+		for i, j := 0, len(partViews)-1; i < j; i, j = i+1, j-1 {
+			partViews[i], partViews[j] = partViews[j], partViews[i]
+		}
+	}
+
+	hasPrev, hasNext := page.Page > 1, more
+	switch {
+	case page.After != 0:
+		hasPrev = true
+	case page.Before != 0:
+		hasPrev, hasNext = more, true
+	}
+
+	// Offer the nearest standard value in stock, if the exact value is missing
+	var nearest *nearestPart
+	if total == 0 && filter.Value != nil && filter.Value.IsEmpty() {
+		others := *filter
+		others.Value = nil
+		where, args := others.SQL()
+		nearest, err = nearestStock(tx, filter.Value.Low.Value(), where, args)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+	}
+
 	categories := []Category{}
 	err = tx.Select(&categories, `SELECT * FROM 'category' ORDER BY "name" ASC`)
 	if err != nil {
@@ -234,20 +535,81 @@ func (app *Application) ListPartsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	currentPage, _ := strconv.Atoi(r.FormValue("page"))
-	prevQuery, nextQuery := pageQuerys(r.URL, currentPage)
+	distributors := []Distributor{}
+	err = tx.Select(&distributors, `SELECT * FROM 'distributor' ORDER BY "name" ASC`)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
 
 	tx.Commit()
 
+	// base contains everything but the position, so it can be used for all
+	// links on the page
+	base := filter.Values()
+	for key, value := range page.Values() {
+		base[key] = value
+	}
+
+	pageURL := func(key string, anchor int64, number int) template.URL {
+		values := make(url.Values)
+		for k, v := range base {
+			values[k] = v
+		}
+		if key != "" {
+			values.Set(key, strconv.FormatInt(anchor, 10))
+		}
+		values.Set("page", strconv.Itoa(number))
+		return template.URL("?" + values.Encode())
+	}
+
+	var prevPage, nextPage template.URL
+	if hasPrev && len(partViews) != 0 {
+		prevPage = pageURL("before", partViews[0].Id, page.Page-1)
+	} else if hasPrev {
+		prevPage = pageURL("", 0, page.Page-1)
+	}
+	if hasNext && len(partViews) != 0 {
+		nextPage = pageURL("after", partViews[len(partViews)-1].Id, page.Page+1)
+	}
+
+	sortURLs := make(map[string]template.URL)
+	for key := range partsSortKeys {
+		values := filter.Values()
+		values.Set("sort", key)
+		if key == page.Sort && !page.Desc {
+			values.Set("order", "desc")
+		} else {
+			values.Set("order", "asc")
+		}
+		if page.PerPage != PartsPerPage {
+			values.Set("per_page", strconv.Itoa(page.PerPage))
+		}
+		sortURLs[key] = template.URL("?" + values.Encode())
+	}
+
 	app.renderTemplate(w, r, map[string]interface{}{
-		"Parts":       partViews,
-		"Categories":  categories,
-		"Places":      places,
-		"CurrentPage": currentPage,
-		"NextPage":    template.URL(nextQuery),
-		"PrevPage":    template.URL(prevQuery),
-		"URL":         r.URL,
-		"Filter":      filter,
+		"Parts":        partViews,
+		"Categories":   categories,
+		"Places":       places,
+		"Distributors": distributors,
+		"Page":         page,
+		"Total":        total,
+		"Nearest":      nearest,
+		"Pages":        (total + page.PerPage - 1) / page.PerPage,
+		"PageSizes":    PageSizes,
+		"LabelLayouts": labels.Layouts,
+		"NextPage":     nextPage,
+		"PrevPage":     prevPage,
+		"SortURLs":     sortURLs,
+		"ExportURLs":   exportURLs("/parts/export", base),
+		"Base":         base,
+		"URL":          r.URL,
+		"Filter":       filter,
+		"SaveView": map[string]string{
+			"Kind":  SavedViewParts,
+			"Query": filter.Values().Encode(),
+		},
 	}, "ListParts", "Layout")
 }
 
@@ -266,10 +628,27 @@ func (app *Application) NewPartHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The form is prefilled by the query, e.g. from an order line
+	query := r.URL.Query()
+	part := &Part{
+		Name: query.Get("name"),
+		Description: sql.NullString{
+			String: query.Get("description"),
+			Valid:  query.Get("description") != "",
+		},
+	}
+	distributorPart := new(DistributorPart)
+	if distributorPart.LoadForm(query) != nil {
+		distributorPart = new(DistributorPart)
+	}
+
 	app.renderTemplate(w, r, map[string]interface{}{
-		"Obj":        &Part{},
-		"Categories": categories,
-		"Places":     places,
+		"Obj":             part,
+		"Amount":          query.Get("amount"),
+		"DistributorPart": distributorPart,
+		"Categories":      categories,
+		"Places":          places,
+		"StockUnits":      StockUnits,
 	}, "NewPart", "Layout")
 }
 
@@ -348,6 +727,33 @@ func (app *Application) EditPartHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	tags := []Tag{}
+	err = tx.Select(&tags, `SELECT 'tag'.* FROM 'tag'
+	JOIN 'part_tag' ON 'part_tag'."tag_id" = 'tag'."id"
+	WHERE 'part_tag'."part_id" = ? ORDER BY 'tag'."name" ASC`, partView.Id)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	partPackagings, err := packagings(tx, partView.Id)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	// The reorder quantity is computed for the needed amount of the reorder
+	// form
+	var partReorder *reorder
+	if need := r.FormValue("need"); need != "" {
+		amount, err := ParseAmount(need, stockUnit(partView.StockUnit))
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+		partReorder = roundUpToPackages(amount, partPackagings)
+	}
+
 	app.renderTemplate(w, r, map[string]interface{}{
 		"Part":             partView,
 		"Categories":       categories,
@@ -356,6 +762,12 @@ func (app *Application) EditPartHandler(w http.ResponseWriter, r *http.Request)
 		"Distributors":     distributors,
 		"Amounts":          partAmounts,
 		"Attachments":      attachments,
+		"Tags":             tags,
+		"StockUnits":       StockUnits,
+		"Packagings":       partPackagings,
+		"Packaged":         formatPackaged(partView.Amount, partView.StockUnit, partPackagings),
+		"Reorder":          partReorder,
+		"Printers":         app.PrintQueue.PrinterNames(),
 	}, "EditPart", "Layout")
 }
 
@@ -375,12 +787,6 @@ func (app *Application) CreatePartAmountHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	amount, err := strconv.Atoi(r.PostFormValue("amount"))
-	if err != nil {
-		app.Error(w, err)
-		return
-	}
-
 	part := new(Part)
 	err = tx.Get(part, `SELECT * FROM 'part' WHERE "id" = ?`, partId)
 	switch err {
@@ -393,6 +799,12 @@ func (app *Application) CreatePartAmountHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	amount, err := partAmountForm(tx, part, r)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
 	lastPartAmount := new(PartAmount)
 	err = tx.Get(lastPartAmount, `SELECT * FROM 'part_amount' WHERE "part_id" = ?
 		ORDER BY "timestamp" DESC LIMIT 1`, part.Id)
@@ -405,8 +817,8 @@ func (app *Application) CreatePartAmountHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	if lastPartAmount != nil && time.Since(lastPartAmount.Timestamp) < 600 * time.Second {
-		lastPartAmount.Amount = int64(amount)
+	if lastPartAmount != nil && time.Since(lastPartAmount.Timestamp) < 600*time.Second {
+		lastPartAmount.Amount = amount
 		lastPartAmount.Timestamp = time.Now()
 
 		err = lastPartAmount.Save(tx)
@@ -423,7 +835,7 @@ func (app *Application) CreatePartAmountHandler(w http.ResponseWriter, r *http.R
 
 	partAmount := &PartAmount{
 		PartId:    part.Id,
-		Amount:    int64(amount),
+		Amount:    amount,
 		Timestamp: time.Now(),
 	}
 
@@ -438,6 +850,95 @@ func (app *Application) CreatePartAmountHandler(w http.ResponseWriter, r *http.R
 	http.Redirect(w, r, fmt.Sprintf("/parts/edit/%d", part.Id), http.StatusSeeOther)
 }
 
+// partAmountForm returns the stock of a stock form, which is either given as
+// amount, as number of packages or as gross weight
+func partAmountForm(db sqlx.Queryer, part *Part, r *http.Request) (float64, error) {
+	if r.PostFormValue("amount") != "" || r.PostFormValue("weight") == "" {
+		if r.PostFormValue("amount") == "" {
+			return 0, errors.New("no stock given")
+		}
+		amount, err := ParseAmount(r.PostFormValue("amount"), stockUnit(part.StockUnit))
+		if err != nil || r.PostFormValue("packaging") == "" {
+			return amount, err
+		}
+		return packagedAmount(db, part, amount, r.PostFormValue("packaging"))
+	}
+
+	weight, err := ParseMass(r.PostFormValue("weight"))
+	if err != nil {
+		return 0, err
+	}
+	tare, err := ParseMass(r.PostFormValue("tare"))
+	if err != nil {
+		return 0, err
+	}
+
+	return part.CountByWeight(weight.Float64, tare)
+}
+
+func (app *Application) CalibratePartHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	part := new(Part)
+	err = tx.Get(part, `SELECT * FROM 'part' WHERE "id" = ?`, id)
+	switch err {
+	case sql.ErrNoRows:
+		app.NotFoundHandler(w, r)
+		return
+	case nil:
+	default:
+		app.Error(w, err)
+		return
+	}
+
+	count, err := strconv.Atoi(r.PostFormValue("count"))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	weight, err := ParseMass(r.PostFormValue("weight"))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tare, err := ParseMass(r.PostFormValue("tare"))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	err = part.Calibrate(int64(count), weight.Float64-tare.Float64)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	err = part.Save(tx)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx.Commit()
+
+	http.Redirect(w, r, fmt.Sprintf("/parts/edit/%d", part.Id), http.StatusSeeOther)
+}
+
 func (app *Application) UpdatePartHandler(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(path.Base(r.URL.Path))
 	if err != nil {
@@ -467,6 +968,9 @@ func (app *Application) UpdatePartHandler(w http.ResponseWriter, r *http.Request
 	}
 
 	err = part.LoadForm(r.PostForm)
+	if err == nil {
+		err = part.CheckValue(tx, r.PostForm.Get("value"))
+	}
 	if err != nil {
 		app.Error(w, err)
 		return
@@ -501,6 +1005,14 @@ func (app *Application) CreatePartHandler(w http.ResponseWriter, r *http.Request
 	part := new(Part)
 	part.CreatedAt = time.Now()
 	err = part.LoadForm(r.PostForm)
+	if err == nil {
+		err = part.CheckValue(tx, r.PostForm.Get("value"))
+	}
+	if err != nil {
+		tx.Rollback()
+		app.Error(w, err)
+		return
+	}
 
 	err = part.Save(tx)
 	if err != nil {
@@ -509,7 +1021,7 @@ func (app *Application) CreatePartHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	amount, err := strconv.Atoi(r.PostForm.Get("amount"))
+	amount, err := ParseAmount(r.PostForm.Get("amount"), stockUnit(part.StockUnit))
 	if err != nil {
 		amount = 0
 	}
@@ -517,7 +1029,7 @@ func (app *Application) CreatePartHandler(w http.ResponseWriter, r *http.Request
 	// Create PartAmount object
 	partAmount := &PartAmount{
 		PartId:    part.Id,
-		Amount:    int64(amount),
+		Amount:    amount,
 		Timestamp: time.Now(),
 	}
 
@@ -528,6 +1040,22 @@ func (app *Application) CreatePartHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Parts created from order lines are linked with the distributor
+	if r.PostForm.Get("key") != "" {
+		distributorPart := &DistributorPart{
+			PartId: part.Id,
+		}
+		err = distributorPart.LoadForm(r.PostForm)
+		if err == nil {
+			err = distributorPart.Save(tx)
+		}
+		if err != nil {
+			tx.Rollback()
+			app.Error(w, err)
+			return
+		}
+	}
+
 	tx.Commit()
 
 	switch next {
@@ -633,6 +1161,10 @@ func (app *Application) CreatePartMergeHandler(w http.ResponseWriter, r *http.Re
 			Valid:  true,
 		},
 		Value:      part.Value,
+		Tolerance:  part.Tolerance,
+		UnitMass:   part.UnitMass,
+		TareMass:   part.TareMass,
+		StockUnit:  part.StockUnit,
 		CategoryId: part.CategoryId,
 		ImageId:    part.ImageId,
 		CreatedAt:  time.Now(),
@@ -651,7 +1183,7 @@ func (app *Application) CreatePartMergeHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if r.PostForm["parts"] == nil || len(r.PostForm["parts"]) < 2{
+	if r.PostForm["parts"] == nil || len(r.PostForm["parts"]) < 2 {
 		http.Redirect(w, r, fmt.Sprintf("/parts/edit/%d", part.Id), http.StatusSeeOther)
 		return
 	}
@@ -661,6 +1193,12 @@ func (app *Application) CreatePartMergeHandler(w http.ResponseWriter, r *http.Re
 		if app.SQLError(w, r, err) {
 			return
 		}
+		// The amounts are summed, so they have to be in the same unit
+		if stockUnit(oldParts[i].StockUnit) != stockUnit(newPart.StockUnit) {
+			http.Error(w, fmt.Sprintf("%s is counted in %s, not in %s", oldParts[i].Name,
+				stockUnit(oldParts[i].StockUnit), stockUnit(newPart.StockUnit)), http.StatusBadRequest)
+			return
+		}
 		attachments := []Attachment{}
 		err = tx.Select(&attachments, `SELECT * FROM 'attachment' WHERE "part_id" = ?`, id)
 		if app.SQLError(w, r, err) {
@@ -742,8 +1280,9 @@ func (app *Application) NewPartMergeHandler(w http.ResponseWriter, r *http.Reque
 
 	similarParts := []PartView{}
 	err = tx.Select(&similarParts, `SELECT * FROM 'part_view' WHERE "id" != ?
-	AND "name" = ? AND "value" = ? AND "category_id" = ?`, part.Id, part.Name,
-		part.Value, part.CategoryId)
+	AND "name" = ? AND "value_decimal" IS ? AND "category_id" = ?
+	AND IFNULL("stock_unit", ?) = ?`, part.Id, part.Name, part.Decimal,
+		part.CategoryId, DefaultStockUnit, stockUnit(part.StockUnit))
 	if app.SQLError(w, r, err) {
 		return
 	}
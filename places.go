@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"path"
 	"strconv"
+
+	"github.com/fritz0705/inventory/labels"
 )
 
 func (app *Application) ListPlacesHandler(w http.ResponseWriter, r *http.Request) {
@@ -110,7 +112,9 @@ func (app *Application) EditPlaceHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	app.renderTemplate(w, r, map[string]interface{}{
-		"Place": place,
+		"Place":        place,
+		"LabelLayouts": labels.Layouts,
+		"Printers":     app.PrintQueue.PrinterNames(),
 	}, "EditPlace", "Layout")
 }
 
@@ -0,0 +1,195 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fritz0705/inventory/labels"
+)
+
+// Status of a print job
+const (
+	PrintQueued   = "queued"
+	PrintPrinting = "printing"
+	PrintDone     = "done"
+	PrintFailed   = "failed"
+)
+
+// maxPrintJobs is the number of jobs which are kept in the queue
+const maxPrintJobs = 100
+
+// ErrPrintQueueFull is returned if too many jobs wait for a printer
+var ErrPrintQueueFull = errors.New("print queue is full")
+
+// A PrintJob is a batch of labels sent to a label printer
+type PrintJob struct {
+	Id         int
+	Printer    string
+	Title      string
+	Labels     int
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+
+	data []byte
+}
+
+// A PrintQueue sends jobs to the printers in the background, every printer
+// has its own worker, so an unreachable printer does not block the others
+type PrintQueue struct {
+	Printers map[string]*labels.Printer
+
+	mu      sync.Mutex
+	jobs    []*PrintJob
+	nextId  int
+	pending map[string]chan *PrintJob
+}
+
+// NewPrintQueue returns a queue and starts the workers of the printers
+func NewPrintQueue(printers map[string]*labels.Printer) *PrintQueue {
+	q := &PrintQueue{
+		Printers: printers,
+		nextId:   1,
+		pending:  make(map[string]chan *PrintJob),
+	}
+	for name, printer := range printers {
+		ch := make(chan *PrintJob, maxPrintJobs)
+		q.pending[name] = ch
+		go q.work(printer, ch)
+	}
+	return q
+}
+
+// PrinterNames returns the names of the printers in order
+func (q *PrintQueue) PrinterNames() []string {
+	names := make([]string, 0, len(q.Printers))
+	for name := range q.Printers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Submit renders the labels and queues them for the printer
+func (q *PrintQueue) Submit(printer, title string, res []labels.Label) (*PrintJob, error) {
+	p, ok := q.Printers[printer]
+	if !ok {
+		return nil, fmt.Errorf("unknown printer %q", printer)
+	}
+	data, err := p.Render(res)
+	if err != nil {
+		return nil, err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job := &PrintJob{
+		Id:        q.nextId,
+		Printer:   printer,
+		Title:     title,
+		Labels:    len(res),
+		Status:    PrintQueued,
+		CreatedAt: time.Now(),
+		data:      data,
+	}
+	select {
+	case q.pending[printer] <- job:
+	default:
+		return nil, ErrPrintQueueFull
+	}
+	q.nextId++
+
+	q.jobs = append(q.jobs, job)
+	if len(q.jobs) > maxPrintJobs {
+		q.jobs = q.jobs[len(q.jobs)-maxPrintJobs:]
+	}
+	return job, nil
+}
+
+// Jobs returns copies of the jobs, the latest first
+func (q *PrintQueue) Jobs() []PrintJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]PrintJob, len(q.jobs))
+	for n, job := range q.jobs {
+		jobs[len(jobs)-1-n] = *job
+	}
+	return jobs
+}
+
+func (q *PrintQueue) setStatus(job *PrintJob, status string, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job.Status = status
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if status == PrintDone || status == PrintFailed {
+		job.FinishedAt = time.Now()
+		job.data = nil
+	}
+}
+
+func (q *PrintQueue) work(printer *labels.Printer, ch chan *PrintJob) {
+	for job := range ch {
+		q.setStatus(job, PrintPrinting, nil)
+		if err := printer.Send(job.data); err != nil {
+			q.setStatus(job, PrintFailed, err)
+		} else {
+			q.setStatus(job, PrintDone, nil)
+		}
+	}
+}
+
+// PrintHandler shows the print queue, labels are printed by posting the
+// printer and the parts, places and place_parts like to the LabelsHandler
+func (app *Application) PrintHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		app.CreatePrintJobHandler(w, r)
+		return
+	}
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"Printers": app.PrintQueue.PrinterNames(),
+		"Jobs":     app.PrintQueue.Jobs(),
+	}, "PrintQueue", "Layout")
+}
+
+func (app *Application) CreatePrintJobHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	res, err := app.collectLabels(app.DB, r.PostForm["parts"], r.PostForm["places"],
+		r.PostForm["place_parts"], baseURL(r))
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+	if len(res) == 0 {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	title := res[0].Title
+	if len(res) > 1 {
+		title = fmt.Sprintf("%s and %d more", title, len(res)-1)
+	}
+	_, err = app.PrintQueue.Submit(r.PostForm.Get("printer"), title, res)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	http.Redirect(w, r, "/print", http.StatusSeeOther)
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 )
 
 type Client struct {
@@ -25,6 +26,7 @@ type Record struct {
 
 var DefaultClient = Client{
 	Endpoint: "http://www.reichelt.de/index.html",
+	Client:   http.Client{Timeout: 10 * time.Second},
 }
 
 func Search(term string) ([]Record, error) {
@@ -55,10 +57,10 @@ func (c *Client) Search(term string) ([]Record, error) {
 	}.Encode()
 
 	res, err := c.Do(req)
-	defer res.Body.Close()
 	if err != nil {
 		return nil, err
 	}
+	defer res.Body.Close()
 
 	rawRecords := []map[string]string{}
 	decoder := json.NewDecoder(res.Body)
@@ -3,29 +3,51 @@ package inventory
 import (
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/fritz0705/inventory/si"
 )
 
 type searchQuery struct {
-	Unit     string
-	Value    si.Number
-	Stock    si.Number
-	Keywords []string
+	Unit      string
+	Value     si.Number
+	Tolerance si.Tolerance
+	Stock     si.Number
+	Codes     []si.Number
+	Keywords  []string
 }
 
 func (s searchQuery) SQL() (query string, args []interface{}) {
-	query += `SELECT * FROM 'part_view' WHERE 1=1`
+	where, args := s.Where()
+	query += `SELECT * FROM 'part_view' WHERE ` + where
+	return
+}
+
+// Where returns a condition for the WHERE clause of a query on part_view and
+// its arguments
+func (s searchQuery) Where() (query string, args []interface{}) {
+	query += `1=1`
 
 	if s.Unit != "" {
 		query += ` AND ("unit" = ? OR "unit_symbol" = ?)`
 		args = append(args, s.Unit, s.Unit)
 	}
 
-	if s.Value.Value() != 0 {
-		query += ` AND "value" = ?`
-		args = append(args, s.Value.Value())
+	if s.Value.Value() != 0 && !s.Tolerance.IsZero() {
+		band := s.Tolerance.Band(s.Value)
+		query += ` AND "value_min" >= ? AND "value_max" <= ?`
+		args = append(args, band.Low, band.High)
+	} else if s.Value.Value() != 0 {
+		query += ` AND "value_decimal" = ?`
+		args = append(args, s.Value.Decimal())
+	}
+
+	if len(s.Codes) != 0 {
+		query += ` AND "value_decimal" IN (?` + strings.Repeat(`, ?`, len(s.Codes)-1) + `)`
+		for _, code := range s.Codes {
+			args = append(args, code.Decimal())
+		}
 	}
 
 	if s.Stock.Value() != 0 {
@@ -34,23 +56,68 @@ func (s searchQuery) SQL() (query string, args []interface{}) {
 	}
 
 	for _, kw := range s.Keywords {
-		query += ` AND "name" LIKE ? OR "name" GLOB ?`
+		query += ` AND ("name" LIKE ? OR "name" GLOB ?)`
 		args = append(args, kw, kw)
 	}
 
 	return
 }
 
+// parseSearchQuery lexes and parses the query string of the search box
+func parseSearchQuery(query string) (*searchQuery, error) {
+	_, c := searchLex("search", query)
+	return loadSearchQuery(c)
+}
+
+// decodeSearchCode decodes a marking code like "472" as resistor and as
+// capacitor value, since the kind of part is not known while searching
+func decodeSearchCode(code string) ([]si.Number, error) {
+	resistor, err := si.DecodeMarking(code)
+	if err != nil {
+		return nil, err
+	}
+	capacitor, err := si.DecodeCapacitorMarking(code)
+	if err != nil {
+		return nil, err
+	}
+	return []si.Number{resistor, capacitor}, nil
+}
+
 func loadSearchQuery(c chan searchItem) (*searchQuery, error) {
 	res := new(searchQuery)
 	for item := range c {
 		var err error
 		switch item.typ {
 		case searchItemUnit:
-			res.Unit = item.val[1 : len(item.val)-1]
+			res.Unit = si.NormalizeUnit(item.val[1 : len(item.val)-1])
 		case searchItemNumber:
-			res.Value, err = si.Parse(strings.Replace(item.val, "_", " ", -1))
+			// Values may have a tolerance like "10k±5%"
+			value, tolerance := si.SplitTolerance(strings.Replace(item.val, "_", " ", -1))
+			var q si.Quantity
+			q, err = si.ParseQuantityBinary(value)
+			if _, ok := err.(*si.UnknownPrefixError); ok {
+				// Part names like "1N4148" or "74HC595" start with a digit
+				res.Keywords = append(res.Keywords, item.val)
+				err = nil
+				break
+			} else if err != nil {
+				break
+			}
+			if tolerance != "" {
+				res.Tolerance, err = si.ParseTolerance(tolerance)
+				if err != nil {
+					break
+				}
+			}
+			res.Value = q.Number
+			if q.Unit != "" {
+				res.Unit = q.Unit
+			}
 		case searchItemText:
+			if strings.HasPrefix(item.val, "code:") {
+				res.Codes, err = decodeSearchCode(item.val[len("code:"):])
+				break
+			}
 			res.Keywords = append(res.Keywords, item.val)
 		case searchItemStock:
 			res.Stock, err = si.Parse(item.val[1 : len(item.val)-1])
@@ -58,6 +125,9 @@ func loadSearchQuery(c chan searchItem) (*searchQuery, error) {
 			res.Keywords = append(res.Keywords, item.val[1:len(item.val)-1])
 		}
 		if err != nil {
+			// The lexer blocks until all items are received
+			for range c {
+			}
 			return nil, err
 		}
 	}
@@ -68,8 +138,7 @@ func loadSearchQuery(c chan searchItem) (*searchQuery, error) {
 func (app *Application) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	query := r.FormValue("query")
 
-	_, c := searchLex("search", query)
-	sq, err := loadSearchQuery(c)
+	sq, err := parseSearchQuery(query)
 	if err != nil {
 		app.Error(w, err)
 		return
@@ -90,9 +159,27 @@ func (app *Application) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var nearest *nearestPart
+	if len(res) == 0 && sq.Value.Value() != 0 {
+		others := *sq
+		others.Value = si.Number{}
+		where, args := others.Where()
+		nearest, err = nearestStock(app.DB, sq.Value.Value(), where, args)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+	}
+
 	app.renderTemplate(w, r, map[string]interface{}{
-		"Parts": res,
-		"Query": sq,
+		"Parts":      res,
+		"Query":      sq,
+		"Nearest":    nearest,
+		"ExportURLs": exportURLs("/search/export", url.Values{"query": {query}}),
+		"SaveView": map[string]string{
+			"Kind":  SavedViewSearch,
+			"Query": query,
+		},
 	}, "Search", "Layout")
 }
 
@@ -263,6 +350,9 @@ func searchLexNumber(l *searchLexer) searchStateFunc {
 	return searchLexAny
 }
 
+// searchLexNumberOrText decides on the first character, so values in RKM
+// notation have to start with a digit: "4k7" and "0R47" are numbers, but
+// "R47" is text, because it is a common part name.
 func searchLexNumberOrText(l *searchLexer) searchStateFunc {
 	switch l.input[l.pos] {
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
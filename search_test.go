@@ -0,0 +1,87 @@
+package inventory
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fritz0705/inventory/si"
+)
+
+func TestSearchQueryWhere(t *testing.T) {
+	type testCase struct {
+		Query string
+		Where string
+	}
+
+	testCases := []testCase{
+		testCase{"foo", `1=1 AND ("name" LIKE ? OR "name" GLOB ?)`},
+		testCase{"foo [Ohm]", `1=1 AND ("unit" = ? OR "unit_symbol" = ?) AND ("name" LIKE ? OR "name" GLOB ?)`},
+		testCase{"10k foo bar", `1=1 AND "value_decimal" = ? AND ("name" LIKE ? OR "name" GLOB ?) AND ("name" LIKE ? OR "name" GLOB ?)`},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		sq, err := parseSearchQuery(testCase.Query)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res, _ := sq.Where(); res != testCase.Where {
+			t.Errorf("Got %s", res)
+		}
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	type testCase struct {
+		Query    string
+		Value    si.Number
+		Unit     string
+		Keywords []string
+	}
+
+	testCases := []testCase{
+		testCase{"10k", si.Number{Significand: 10, Exponent: si.Kilo}, "", nil},
+		testCase{"4k7 [Ohm]", si.Number{Significand: 4.7, Exponent: si.Kilo}, "Ω", nil},
+		testCase{"100n [uF]", si.Number{Significand: 100, Exponent: si.Nano}, "µF", nil},
+		testCase{"4.7kohm", si.Number{Significand: 4.7, Exponent: si.Kilo}, "Ω", nil},
+		testCase{"1N4148", si.Number{}, "", []string{"1N4148"}},
+		testCase{"2N2222 transistor", si.Number{}, "", []string{"2N2222", "transistor"}},
+		testCase{"74HC595", si.Number{}, "", []string{"74HC595"}},
+		testCase{"64Ki eeprom", si.Number{Significand: 65536}, "", []string{"eeprom"}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := parseSearchQuery(testCase.Query)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if res.Value != testCase.Value || res.Unit != testCase.Unit ||
+			!reflect.DeepEqual(res.Keywords, testCase.Keywords) {
+			t.Errorf("Got %+v", res)
+		}
+	}
+}
+
+func TestParseSearchQueryError(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for n := 0; n < 20; n++ {
+		if _, err := parseSearchQuery("<many> foo bar [Ohm]"); err == nil {
+			t.Fatal("Got no error")
+		}
+	}
+
+	// The lexers finish after their items are received
+	for n := 0; n < 100 && runtime.NumGoroutine() > before; n++ {
+		time.Sleep(time.Millisecond)
+	}
+	if res := runtime.NumGoroutine(); res > before {
+		t.Errorf("Got %d goroutines, wants %d", res, before)
+	}
+}
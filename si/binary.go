@@ -0,0 +1,87 @@
+package si
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// BinaryPrefix represents the exponent to 1024 of an IEC binary prefix, which
+// are used for memory sizes
+type BinaryPrefix int
+
+const (
+	Kibi BinaryPrefix = iota + 1
+	Mebi
+	Gibi
+	Tebi
+	Pebi
+	Exbi
+	Zebi
+	Yobi
+)
+
+var binaryPrefixStrings = []string{"", "Ki", "Mi", "Gi", "Ti", "Pi", "Ei", "Zi", "Yi"}
+
+func (p BinaryPrefix) String() string {
+	if p < 0 || int(p) >= len(binaryPrefixStrings) {
+		return ""
+	}
+	return binaryPrefixStrings[p]
+}
+
+// Factor returns the value of the prefix, e.g. 1024 for Kibi
+func (p BinaryPrefix) Factor() float64 {
+	return math.Pow(1024, float64(p))
+}
+
+// ParseBinary converts a string to a Number like Parse, but additionally
+// accepts the IEC binary prefixes like "Ki" or "Mi", so "4Ki" is 4096. The
+// resulting Number has no prefix.
+func ParseBinary(s string) (num Number, err error) {
+	s = strings.TrimSpace(s)
+	for p := Yobi; p >= Kibi; p-- {
+		if !strings.HasSuffix(s, p.String()) {
+			continue
+		}
+		sig := strings.TrimSpace(s[:len(s)-len(p.String())])
+		if !strings.ContainsRune(sig, '.') {
+			sig = strings.Replace(sig, ",", ".", 1)
+		}
+		num.Significand, err = strconv.ParseFloat(sig, 64)
+		if err != nil {
+			return Number{}, err
+		}
+		num.Significand *= p.Factor()
+		return
+	}
+	return Parse(s)
+}
+
+// ParseQuantityBinary parses a quantity like ParseQuantity, but numbers
+// without unit may also have an IEC binary prefix, like memory sizes such as
+// "64Ki". This is the opt-in binary mode for part values and searches.
+func ParseQuantityBinary(s string) (q Quantity, err error) {
+	q, err = ParseQuantity(s)
+	if _, ok := err.(*UnknownPrefixError); ok {
+		if num, binaryErr := ParseBinary(s); binaryErr == nil {
+			return Quantity{Number: num}, nil
+		}
+	}
+	return
+}
+
+// Binary returns a string representation of a Number using the largest
+// binary prefix which results in a significand of at least 1, e.g. "4Ki" for
+// 4096
+func (n Number) Binary() string {
+	val := n.Value()
+	prefix := BinaryPrefix(0)
+	for p := Yobi; p >= Kibi; p-- {
+		if math.Abs(val) >= p.Factor() {
+			prefix = p
+			break
+		}
+	}
+	return strconv.FormatFloat(val/prefix.Factor(), 'f', -1, 64) + prefix.String()
+}
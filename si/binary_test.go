@@ -0,0 +1,97 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestParseBinary(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result float64
+	}
+
+	testCases := []testCase{
+		testCase{"4Ki", 4096},
+		testCase{"4 Ki", 4096},
+		testCase{"1.5Mi", 1572864},
+		testCase{"16Gi", 17179869184},
+		testCase{"2,5Ki", 2560},
+		testCase{"1k", 1000},
+		testCase{"512", 512},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseBinary(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res.Value() != testCase.Result {
+			t.Errorf("ParseBinary(%#v) should return %v, got %v", testCase.Data,
+				testCase.Result, res.Value())
+		}
+	}
+
+	for _, data := range []string{"4Xi", "xKi", "1.2.3Mi"} {
+		if res, err := ParseBinary(data); err == nil {
+			t.Errorf("ParseBinary(%#v) should fail, got %v", data, res)
+		} else if res.Value() != 0 {
+			t.Errorf("ParseBinary(%#v) should return no value with the error, got %v",
+				data, res.Value())
+		}
+	}
+}
+
+func TestParseQuantityBinary(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result float64
+		Unit   string
+	}
+
+	testCases := []testCase{
+		testCase{"64Ki", 65536, ""},
+		testCase{"2 Mi", 2097152, ""},
+		testCase{"4.7kΩ", 4700, "Ω"},
+		testCase{"100n", 100e-9, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseQuantityBinary(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res.Value() != testCase.Result || res.Unit != testCase.Unit {
+			t.Errorf("ParseQuantityBinary(%#v) should return %v %s, got %v", testCase.Data,
+				testCase.Result, testCase.Unit, res)
+		}
+	}
+
+	if _, err := ParseQuantityBinary("10x"); err == nil {
+		t.Error("ParseQuantityBinary(\"10x\") should fail")
+	}
+}
+
+func TestBinary(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{New(4096), "4Ki"},
+		testCase{New(1572864), "1.5Mi"},
+		testCase{New(512), "512"},
+		testCase{New(1 << 30), "1Gi"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := testCase.Data.Binary(); res != testCase.Result {
+			t.Errorf("%#v.Binary() should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
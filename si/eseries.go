@@ -0,0 +1,134 @@
+package si
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Series is an IEC 60063 series of preferred numbers. The values are the
+// significands of one decade, with two digits for E6 to E24 and three digits
+// for E48 to E192.
+type Series struct {
+	Name   string
+	Values []int
+}
+
+var (
+	E6 = Series{"E6", []int{10, 15, 22, 33, 47, 68}}
+
+	E12 = Series{"E12", []int{10, 12, 15, 18, 22, 27, 33, 39, 47, 56, 68, 82}}
+
+	E24 = Series{"E24", []int{
+		10, 11, 12, 13, 15, 16, 18, 20, 22, 24, 27, 30,
+		33, 36, 39, 43, 47, 51, 56, 62, 68, 75, 82, 91,
+	}}
+
+	E48 = Series{"E48", []int{
+		100, 105, 110, 115, 121, 127, 133, 140, 147, 154, 162, 169,
+		178, 187, 196, 205, 215, 226, 237, 249, 261, 274, 287, 301,
+		316, 332, 348, 365, 383, 402, 422, 442, 464, 487, 511, 536,
+		562, 590, 619, 649, 681, 715, 750, 787, 825, 866, 909, 953,
+	}}
+
+	E96 = Series{"E96", EIA96Values}
+
+	E192 = Series{"E192", []int{
+		100, 101, 102, 104, 105, 106, 107, 109, 110, 111, 113, 114,
+		115, 117, 118, 120, 121, 123, 124, 126, 127, 129, 130, 132,
+		133, 135, 137, 138, 140, 142, 143, 145, 147, 149, 150, 152,
+		154, 156, 158, 160, 162, 164, 165, 167, 169, 172, 174, 176,
+		178, 180, 182, 184, 187, 189, 191, 193, 196, 198, 200, 203,
+		205, 208, 210, 213, 215, 218, 221, 223, 226, 229, 232, 234,
+		237, 240, 243, 246, 249, 252, 255, 258, 261, 264, 267, 271,
+		274, 277, 280, 284, 287, 291, 294, 298, 301, 305, 309, 312,
+		316, 320, 324, 328, 332, 336, 340, 344, 348, 352, 357, 361,
+		365, 370, 374, 379, 383, 388, 392, 397, 402, 407, 412, 417,
+		422, 427, 432, 437, 442, 448, 453, 459, 464, 470, 475, 481,
+		487, 493, 499, 505, 511, 517, 523, 530, 536, 542, 549, 556,
+		562, 569, 576, 583, 590, 597, 604, 612, 619, 626, 634, 642,
+		649, 657, 665, 673, 681, 690, 698, 706, 715, 723, 732, 741,
+		750, 759, 768, 777, 787, 796, 806, 816, 825, 835, 845, 856,
+		866, 876, 887, 898, 909, 920, 931, 942, 953, 965, 976, 988,
+	}}
+)
+
+// ESeries are all known series, from the coarsest to the finest
+var ESeries = []Series{E6, E12, E24, E48, E96, E192}
+
+// ParseSeries returns the series with the given name, like "E24"
+func ParseSeries(name string) (Series, bool) {
+	for _, series := range ESeries {
+		if strings.EqualFold(series.Name, name) {
+			return series, true
+		}
+	}
+	return Series{}, false
+}
+
+func (s Series) String() string {
+	return s.Name
+}
+
+// digits returns the number of significant digits of the series
+func (s Series) digits() int {
+	return len(strconv.Itoa(s.Values[0]))
+}
+
+// Contains reports whether the value of n is a value of the series
+func (s Series) Contains(n Number) bool {
+	val := n.Value()
+	if val <= 0 || len(s.Values) == 0 {
+		return false
+	}
+
+	d, _ := digits(val)
+	if len(d) > s.digits() {
+		return false
+	}
+	d += strings.Repeat("0", s.digits()-len(d))
+	significand, _ := strconv.Atoi(d)
+
+	for _, value := range s.Values {
+		if value == significand {
+			return true
+		}
+	}
+	return false
+}
+
+// Nearest returns the value of the series which is nearest to n. The distance
+// is measured logarithmically, like the series itself is spaced. Values less
+// than or equal to zero are returned unchanged.
+func (s Series) Nearest(n Number) Number {
+	val := n.Value()
+	if val <= 0 || len(s.Values) == 0 {
+		return n
+	}
+
+	_, exp := digits(val)
+	exp -= s.digits() - 1
+
+	// The first value of the next decade might be nearer than the last value
+	// of this decade
+	best, bestExp, bestDist := 0, 0, math.Inf(1)
+	candidates := append(s.Values[:len(s.Values):len(s.Values)], s.Values[0]*10)
+	for _, candidate := range candidates {
+		c := float64(candidate) * math.Pow10(exp)
+		dist := math.Abs(math.Log(c / val))
+		if dist < bestDist {
+			best, bestExp, bestDist = candidate, exp, dist
+		}
+	}
+	return scaled(best, bestExp)
+}
+
+// SeriesOf returns the coarsest series which contains the value of n
+func SeriesOf(n Number) (Series, bool) {
+	for _, series := range ESeries {
+		if series.Contains(n) {
+			return series, true
+		}
+	}
+	return Series{}, false
+}
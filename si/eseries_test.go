@@ -0,0 +1,84 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestSeriesLength(t *testing.T) {
+	for n, series := range ESeries {
+		if len(series.Values) != 6<<uint(n) {
+			t.Errorf("%v should have %d values, got %d", series, 6<<uint(n),
+				len(series.Values))
+		}
+	}
+
+	// Every second value of a three-digit series belongs to the coarser one
+	for _, pair := range [][2]Series{{E48, E96}, {E96, E192}} {
+		for n, value := range pair[0].Values {
+			if pair[1].Values[2*n] != value {
+				t.Errorf("%v value %d not in %v", pair[0], value, pair[1])
+			}
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	type testCase struct {
+		Series Series
+		Data   Number
+		Result float64
+	}
+
+	testCases := []testCase{
+		testCase{E12, Number{5, Kilo}, 4700},
+		testCase{E12, Number{5.2, Kilo}, 5600},
+		testCase{E24, Number{5, Kilo}, 5100},
+		testCase{E12, Number{9, None}, 8.2},
+		testCase{E12, Number{9.5, None}, 10},
+		testCase{E6, Number{130, Nano}, 150e-9},
+		testCase{E96, Number{10.05, Kilo}, 10000},
+		testCase{E96, Number{49.5, None}, 49.9},
+		testCase{E192, Number{4.7, Kilo}, 4700},
+		testCase{E24, Number{0.33, None}, 0.33},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res := testCase.Series.Nearest(testCase.Data)
+		if res.Value() != testCase.Result {
+			t.Errorf("%v.Nearest(%v) should return %v, got %v", testCase.Series,
+				testCase.Data, testCase.Result, res.Value())
+		}
+	}
+}
+
+func TestSeriesOf(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{Number{4.7, Kilo}, "E6"},
+		testCase{Number{100, Nano}, "E6"},
+		testCase{Number{8.2, None}, "E12"},
+		testCase{Number{5.1, Kilo}, "E24"},
+		testCase{Number{10.5, Kilo}, "E48"},
+		testCase{Number{49.9, None}, "E96"},
+		testCase{Number{4.75, Kilo}, "E96"},
+		testCase{Number{1.01, Mega}, "E192"},
+		testCase{Number{4.8, Kilo}, ""},
+		testCase{Number{0, None}, ""},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		series, ok := SeriesOf(testCase.Data)
+		if ok != (testCase.Result != "") || series.Name != testCase.Result {
+			t.Errorf("SeriesOf(%v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, series.Name)
+		}
+	}
+}
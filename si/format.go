@@ -0,0 +1,65 @@
+package si
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format describes how Number.Format represents a number. The zero value
+// results in the same output as Canon().String().
+type Format struct {
+	// Digits is the number of significant digits, zero means as many as
+	// needed
+	Digits int
+	// ASCII uses "u" instead of "μ" as micro prefix
+	ASCII bool
+	// DecimalComma uses a comma as decimal separator, like in "4,7k"
+	DecimalComma bool
+	// FixedPrefix uses Prefix for every number instead of the best matching
+	// prefix, e.g. to show all capacitors in nF
+	FixedPrefix bool
+	Prefix      Prefix
+}
+
+// WithPrefix returns a copy of the format which always uses the given prefix
+func (f Format) WithPrefix(prefix Prefix) Format {
+	f.FixedPrefix, f.Prefix = true, prefix
+	return f
+}
+
+// Format returns a string representation of the number according to the
+// format f. Like in String, the prefixes "E" and "p" are separated by a space.
+func (n Number) Format(f Format) string {
+	if f.FixedPrefix {
+		n = Number{scale(round(n.Value()), -int(f.Prefix)), f.Prefix}
+	} else {
+		n = n.Canon()
+	}
+
+	if f.Digits > 0 {
+		n.Significand, _ = strconv.ParseFloat(
+			strconv.FormatFloat(n.Significand, 'e', f.Digits-1, 64), 64)
+		// Rounding might result in 1000, which has a better prefix
+		if !f.FixedPrefix && (n.Significand >= 1000 || n.Significand <= -1000) {
+			n = n.Canon()
+		}
+	}
+
+	s := strconv.FormatFloat(n.Significand, 'f', -1, 64)
+	if f.DecimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+
+	prefix := n.Exponent.String()
+	if f.ASCII && n.Exponent == Micro {
+		prefix = "u"
+	}
+
+	switch n.Exponent {
+	case None:
+		return s
+	case Exa, Pico:
+		return s + " " + prefix
+	}
+	return s + prefix
+}
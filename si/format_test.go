@@ -0,0 +1,49 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Format Format
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{Number{4700, None}, Format{}, "4.7k"},
+		testCase{New(4.699999999999999e-12), Format{}, "4.7 p"},
+		testCase{Number{4.7, Micro}, Format{}, "4.7μ"},
+		testCase{Number{4.7, Micro}, Format{ASCII: true}, "4.7u"},
+		testCase{Number{4.7, Kilo}, Format{DecimalComma: true}, "4,7k"},
+		testCase{Number{1.23456, Kilo}, Format{Digits: 3}, "1.23k"},
+		testCase{Number{999.96, None}, Format{Digits: 3}, "1k"},
+		testCase{Number{100, Nano}, Format{}.WithPrefix(Nano), "100n"},
+		testCase{Number{1, Micro}, Format{}.WithPrefix(Nano), "1000n"},
+		testCase{Number{4.7, Pico}, Format{}.WithPrefix(Nano), "0.0047n"},
+		testCase{Number{330, None}, Format{}.WithPrefix(Hecto), "3.3h"},
+		testCase{Number{4.7, Kilo}, Format{}.WithPrefix(None), "4700"},
+		testCase{Number{1, Micro}, Format{ASCII: true, DecimalComma: true}.WithPrefix(Micro), "1u"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := testCase.Data.Format(testCase.Format); res != testCase.Result {
+			t.Errorf("%#v.Format(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Format, testCase.Result, res)
+		}
+	}
+}
+
+func TestParseComma(t *testing.T) {
+	for _, s := range []string{"4,7k", "4,7 k", "4.7k"} {
+		res, err := Parse(s)
+		if err != nil {
+			t.Error(err)
+		} else if res.Value() != 4700 {
+			t.Errorf("Parse(%#v) should return 4.7k, got %v", s, res)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package si
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrMarking is returned when a value can't be represented by the requested
+// marking code
+var ErrMarking = errors.New("si: value not representable by marking code")
+
+// EIA96Values are the significands of the EIA-96 code, the value of code
+// "01" is EIA96Values[0]
+var EIA96Values = []int{
+	100, 102, 105, 107, 110, 113, 115, 118, 121, 124, 127, 130,
+	133, 137, 140, 143, 147, 150, 154, 158, 162, 165, 169, 174,
+	178, 182, 187, 191, 196, 200, 205, 210, 215, 221, 226, 232,
+	237, 243, 249, 255, 261, 267, 274, 280, 287, 294, 301, 309,
+	316, 324, 332, 340, 348, 357, 365, 374, 383, 392, 402, 412,
+	422, 432, 442, 453, 464, 475, 487, 499, 511, 523, 536, 549,
+	562, 576, 590, 604, 619, 634, 649, 665, 681, 698, 715, 732,
+	750, 768, 787, 806, 825, 845, 866, 887, 909, 931, 953, 976,
+}
+
+// EIA96Multipliers maps the letters of the EIA-96 code to the exponent of
+// their multiplier
+var EIA96Multipliers = map[byte]int{
+	'Z': -3,
+	'Y': -2,
+	'R': -2,
+	'X': -1,
+	'S': -1,
+	'A': 0,
+	'B': 1,
+	'H': 1,
+	'C': 2,
+	'D': 3,
+	'E': 4,
+	'F': 5,
+}
+
+// eia96Letters are the letters used when encoding EIA-96 codes
+var eia96Letters = map[int]byte{
+	-3: 'Z',
+	-2: 'Y',
+	-1: 'X',
+	0:  'A',
+	1:  'B',
+	2:  'C',
+	3:  'D',
+	4:  'E',
+	5:  'F',
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// scaled returns the Number significand * 10^exp, choosing the best prefix
+func scaled(significand int, exp int) Number {
+	return New(scale(float64(significand), exp)).Canon()
+}
+
+// DecodeMarking decodes the marking code of a SMD resistor. It accepts the
+// 3-digit code ("472" is 4.7 k), the 4-digit code ("1002" is 10 k), codes
+// with R as decimal point ("4R7", "R47") and the EIA-96 code ("01C" is 10 k).
+//
+// In 3-digit codes, the multipliers 8 and 9 stand for 0.01 and 0.1.
+func DecodeMarking(code string) (Number, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	syntaxError := &strconv.NumError{Func: "DecodeMarking", Num: code, Err: strconv.ErrSyntax}
+
+	switch {
+	case strings.ContainsRune(code, 'R') && len(code) <= 4 && code[len(code)-1] != 'R':
+		if !isDigits(strings.Replace(code, "R", "", 1)) {
+			return Number{}, syntaxError
+		}
+		return ParseRKM(code)
+	case len(code) == 3 && isDigits(code[:2]) && code[2] >= 'A' && code[2] <= 'Z':
+		index, _ := strconv.Atoi(code[:2])
+		multiplier, ok := EIA96Multipliers[code[2]]
+		if !ok || index < 1 || index > len(EIA96Values) {
+			return Number{}, syntaxError
+		}
+		return scaled(EIA96Values[index-1], multiplier), nil
+	case (len(code) == 3 || len(code) == 4) && isDigits(code):
+		significand, _ := strconv.Atoi(code[:len(code)-1])
+		multiplier := int(code[len(code)-1] - '0')
+		if len(code) == 3 && multiplier >= 8 {
+			multiplier -= 10
+		}
+		return scaled(significand, multiplier), nil
+	}
+
+	return Number{}, syntaxError
+}
+
+// DecodeCapacitorMarking decodes the 3-digit marking code of a capacitor,
+// which denotes the capacity in pF ("104" is 100 n).
+func DecodeCapacitorMarking(code string) (Number, error) {
+	num, err := DecodeMarking(code)
+	if err != nil {
+		return num, err
+	}
+	num.Exponent += Pico
+	return num.Canon(), nil
+}
+
+// digits returns the significant decimal digits of a value and the exponent of
+// the first digit, so that 4700 yields "47" and 3. The value is rounded to 15
+// digits to get rid of floating point artifacts.
+func digits(val float64) (string, int) {
+	s := strconv.FormatFloat(val, 'e', 14, 64)
+	parts := strings.SplitN(s, "e", 2)
+	exp, _ := strconv.Atoi(parts[1])
+	return strings.TrimRight(strings.Replace(parts[0], ".", "", 1), "0"), exp
+}
+
+// markingCode encodes a value with the given number of significant digits,
+// shift is added to the decimal exponent of the value before encoding.
+func markingCode(n Number, significant int, shift int) (string, error) {
+	val := n.Value()
+	if val <= 0 {
+		return "", ErrMarking
+	}
+
+	d, exp := digits(val)
+	exp += shift
+	if len(d) > significant {
+		return "", ErrMarking
+	}
+	d += strings.Repeat("0", significant-len(d))
+
+	multiplier := exp - significant + 1
+	switch {
+	case multiplier >= 0 && multiplier <= 9:
+		return d + strconv.Itoa(multiplier), nil
+	case exp >= -1 && exp < significant-1:
+		// Small values use R as decimal point, like 4R7 or R47
+		point := exp + 1
+		return d[:point] + "R" + d[point:], nil
+	}
+	return "", ErrMarking
+}
+
+// Marking returns the 3-digit marking code of a resistor value, e.g. "472" for
+// 4.7 k
+func (n Number) Marking() (string, error) {
+	return markingCode(n, 2, 0)
+}
+
+// Marking4 returns the 4-digit marking code of a resistor value, e.g. "1002"
+// for 10 k
+func (n Number) Marking4() (string, error) {
+	return markingCode(n, 3, 0)
+}
+
+// CapacitorMarking returns the 3-digit marking code of a capacitor value,
+// e.g. "104" for 100 n
+func (n Number) CapacitorMarking() (string, error) {
+	return markingCode(n, 2, -int(Pico))
+}
+
+// EIA96 returns the EIA-96 marking code of a resistor value, e.g. "01C" for
+// 10 k. Only values of the E96 series can be represented.
+func (n Number) EIA96() (string, error) {
+	val := n.Value()
+	if val <= 0 {
+		return "", ErrMarking
+	}
+
+	d, exp := digits(val)
+	if len(d) > 3 {
+		return "", ErrMarking
+	}
+	d += strings.Repeat("0", 3-len(d))
+	significand, _ := strconv.Atoi(d)
+
+	letter, ok := eia96Letters[exp-2]
+	if !ok {
+		return "", ErrMarking
+	}
+
+	for index, value := range EIA96Values {
+		if value == significand {
+			return strconv.Itoa(100 + index + 1)[1:] + string(letter), nil
+		}
+	}
+	return "", ErrMarking
+}
@@ -0,0 +1,114 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestDecodeMarking(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result float64
+	}
+
+	testCases := []testCase{
+		testCase{"472", 4700},
+		testCase{"100", 10},
+		testCase{"101", 100},
+		testCase{"225", 2200000},
+		testCase{"4R7", 4.7},
+		testCase{"R47", 0.47},
+		testCase{"1002", 10000},
+		testCase{"4701", 4700},
+		testCase{"10R0", 10},
+		testCase{"01C", 10000},
+		testCase{"01c", 10000},
+		testCase{"68X", 49.9},
+		testCase{"96A", 976},
+		testCase{"01R", 1},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := DecodeMarking(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res.Value() != testCase.Result {
+			t.Errorf("DecodeMarking(%#v) should return %v, got %v", testCase.Data,
+				testCase.Result, res.Value())
+		}
+	}
+
+	for _, data := range []string{"", "47", "12345", "4R7R", "00A", "97A", "01Q", "4R"} {
+		if res, err := DecodeMarking(data); err == nil {
+			t.Errorf("DecodeMarking(%#v) should fail, got %#v", data, res)
+		}
+	}
+}
+
+func TestDecodeCapacitorMarking(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Number
+	}
+
+	testCases := []testCase{
+		testCase{"104", Number{100, Nano}},
+		testCase{"472", Number{4.7, Nano}},
+		testCase{"220", Number{22, Pico}},
+		testCase{"109", Number{1, Pico}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := DecodeCapacitorMarking(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res.String() != testCase.Result.String() {
+			t.Errorf("DecodeCapacitorMarking(%#v) should return %v, got %v",
+				testCase.Data, testCase.Result, res)
+		}
+	}
+}
+
+func TestMarking(t *testing.T) {
+	type testCase struct {
+		Data      Number
+		Marking   string
+		Marking4  string
+		EIA96     string
+		Capacitor string
+	}
+
+	testCases := []testCase{
+		testCase{Number{4.7, Kilo}, "472", "4701", "", ""},
+		testCase{Number{10, Kilo}, "103", "1002", "01C", ""},
+		testCase{Number{4.7, Micro}, "", "", "", "475"},
+		testCase{Number{4.7, None}, "4R7", "4R70", "", ""},
+		testCase{Number{0.47, None}, "R47", "R470", "", ""},
+		testCase{Number{49.9, None}, "", "49R9", "68X", ""},
+		testCase{Number{100, Nano}, "", "", "", "104"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		check := func(name string, f func() (string, error), expected string) {
+			res, err := f()
+			if expected == "" && err == nil {
+				t.Errorf("%#v.%s() should fail, got %#v", testCase.Data, name, res)
+			} else if expected != "" && err != nil {
+				t.Errorf("%#v.%s() failed: %v", testCase.Data, name, err)
+			} else if res != expected {
+				t.Errorf("%#v.%s() should return %#v, got %#v", testCase.Data, name,
+					expected, res)
+			}
+		}
+
+		check("Marking", testCase.Data.Marking, testCase.Marking)
+		check("Marking4", testCase.Data.Marking4, testCase.Marking4)
+		check("EIA96", testCase.Data.EIA96, testCase.EIA96)
+		check("CapacitorMarking", testCase.Data.CapacitorMarking, testCase.Capacitor)
+	}
+}
@@ -0,0 +1,161 @@
+package si
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Units maps the unit symbols known to ParseQuantity to their names. Symbols
+// which are also prefixes, like "m" for metre, are left out on purpose,
+// because "1m" would be ambiguous.
+var Units = map[string]string{
+	"Ω":  "ohm",
+	"F":  "farad",
+	"H":  "henry",
+	"V":  "volt",
+	"A":  "ampere",
+	"W":  "watt",
+	"Hz": "hertz",
+	"s":  "second",
+	"S":  "siemens",
+	"C":  "coulomb",
+	"J":  "joule",
+	"Wh": "watt hour",
+	"Ah": "ampere hour",
+	"VA": "volt-ampere",
+	"°C": "degree Celsius",
+}
+
+// unitAliases maps alternative spellings to the symbols of Units
+var unitAliases = map[string]string{
+	"\u2126": "Ω", // OHM SIGN
+	"Ohm":    "Ω",
+	"ohm":    "Ω",
+	"Ohms":   "Ω",
+	"ohms":   "Ω",
+}
+
+// unitStrings contains the keys of Units and unitAliases, longest first
+var unitStrings []string
+
+// A Quantity is a Number combined with a unit symbol like "Ω" or "F". An empty
+// unit denotes a dimensionless quantity.
+type Quantity struct {
+	Number
+	Unit string
+}
+
+// A UnitError is returned when quantities of different units are combined
+type UnitError struct {
+	Func  string
+	Left  string
+	Right string
+}
+
+func (e *UnitError) Error() string {
+	return fmt.Sprintf("si: %s: unit %q does not match %q", e.Func, e.Left, e.Right)
+}
+
+// NormalizeUnit returns the canonical symbol of a unit, e.g. "Ω" for "Ohm".
+// Units of Units with the micro prefix are written with the micro sign, e.g.
+// "µF" for "uF". Unknown units are returned unchanged.
+func NormalizeUnit(unit string) string {
+	unit = strings.TrimSpace(unit)
+	if symbol, ok := unitAliases[unit]; ok {
+		return symbol
+	}
+	for _, micro := range []string{"u", "\u03bc", "µ"} {
+		if !strings.HasPrefix(unit, micro) {
+			continue
+		}
+		if symbol := NormalizeUnit(unit[len(micro):]); Units[symbol] != "" {
+			return "µ" + symbol
+		}
+	}
+	return unit
+}
+
+// ParseQuantity converts a string like "4.7kΩ", "100 nF", "10mA" or "3V3" to a
+// Quantity. The unit has to be one of Units, it can also take the place of the
+// decimal point like in the RKM notation. Strings without unit are parsed by
+// Parse and result in a dimensionless quantity.
+func ParseQuantity(s string) (q Quantity, err error) {
+	s = strings.TrimSpace(s)
+	for _, unit := range unitStrings {
+		if strings.HasSuffix(s, unit) && len(s) > len(unit) {
+			q.Number, err = Parse(strings.TrimSpace(s[:len(s)-len(unit)]))
+			q.Unit = NormalizeUnit(unit)
+			return
+		}
+		if pos := strings.Index(s, unit); pos > 0 && isDigits(s[:pos]) &&
+			isDigits(s[pos+len(unit):]) {
+			q.Number.Significand, err = strconv.ParseFloat(s[:pos]+"."+s[pos+len(unit):], 64)
+			q.Unit = NormalizeUnit(unit)
+			return
+		}
+	}
+	q.Number, err = Parse(s)
+	return
+}
+
+// ParseQuantityIn parses a quantity which is expected to have the given unit.
+// The unit may be omitted in s, any other unit results in a *UnitError. Unlike
+// ParseQuantity, the unit does not have to be one of Units.
+func ParseQuantityIn(s string, unit string) (q Quantity, err error) {
+	s = strings.TrimSpace(s)
+	unit = NormalizeUnit(unit)
+	if unit != "" && strings.HasSuffix(s, unit) && len(s) > len(unit) {
+		q.Number, err = Parse(strings.TrimSpace(s[:len(s)-len(unit)]))
+		q.Unit = unit
+		return
+	}
+
+	q, err = ParseQuantity(s)
+	if err != nil {
+		return
+	}
+	if q.Unit == "" {
+		q.Unit = unit
+	} else if q.Unit != unit {
+		err = &UnitError{"ParseQuantityIn", q.Unit, unit}
+	}
+	return
+}
+
+// String returns a string representation of a Quantity, which can be read by
+// ParseQuantity
+func (q Quantity) String() string {
+	return q.Number.String() + q.Unit
+}
+
+// Canon returns the Quantity with the best matching SI prefix
+func (q Quantity) Canon() Quantity {
+	return Quantity{q.Number.Canon(), q.Unit}
+}
+
+// Cmp compares the values of two quantities and returns -1, 0 or +1. It fails
+// with a *UnitError if the quantities have different units.
+func (q Quantity) Cmp(o Quantity) (int, error) {
+	if q.Unit != o.Unit {
+		return 0, &UnitError{"Cmp", q.Unit, o.Unit}
+	}
+	switch a, b := q.Value(), o.Value(); {
+	case a < b:
+		return -1, nil
+	case a > b:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func init() {
+	for unit := range Units {
+		unitStrings = append(unitStrings, unit)
+	}
+	for alias := range unitAliases {
+		unitStrings = append(unitStrings, alias)
+	}
+	sort.Sort(byLength(unitStrings))
+}
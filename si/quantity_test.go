@@ -0,0 +1,138 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestParseQuantity(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Quantity
+	}
+
+	testCases := []testCase{
+		testCase{"4.7kΩ", Quantity{Number{4.7, Kilo}, "Ω"}},
+		testCase{"4.7 kΩ", Quantity{Number{4.7, Kilo}, "Ω"}},
+		testCase{"4k7Ω", Quantity{Number{4.7, Kilo}, "Ω"}},
+		testCase{"4.7kOhm", Quantity{Number{4.7, Kilo}, "Ω"}},
+		testCase{"4.7k\u2126", Quantity{Number{4.7, Kilo}, "Ω"}},
+		testCase{"100nF", Quantity{Number{100, Nano}, "F"}},
+		testCase{"100 nF", Quantity{Number{100, Nano}, "F"}},
+		testCase{"22 pF", Quantity{Number{22, Pico}, "F"}},
+		testCase{"10µF", Quantity{Number{10, Micro}, "F"}},
+		testCase{"3V3", Quantity{Number{3.3, None}, "V"}},
+		testCase{"12V", Quantity{Number{12, None}, "V"}},
+		testCase{"10mA", Quantity{Number{10, Milli}, "A"}},
+		testCase{"16MHz", Quantity{Number{16, Mega}, "Hz"}},
+		testCase{"2200mAh", Quantity{Number{2200, Milli}, "Ah"}},
+		testCase{"4.7k", Quantity{Number{4.7, Kilo}, ""}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseQuantity(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res != testCase.Result {
+			t.Errorf("ParseQuantity(%#v) should return %v, got %v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestParseQuantityIn(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Unit   string
+		Result Quantity
+		Fails  bool
+	}
+
+	testCases := []testCase{
+		testCase{"4.7k", "Ω", Quantity{Number{4.7, Kilo}, "Ω"}, false},
+		testCase{"4.7kΩ", "Ω", Quantity{Number{4.7, Kilo}, "Ω"}, false},
+		testCase{"4.7kOhm", "Ohm", Quantity{Number{4.7, Kilo}, "Ω"}, false},
+		testCase{"1.5m", "m", Quantity{Number{1.5, None}, "m"}, false},
+		testCase{"100nF", "Ω", Quantity{}, true},
+		testCase{"3V3", "A", Quantity{}, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseQuantityIn(testCase.Data, testCase.Unit)
+		if testCase.Fails {
+			if _, ok := err.(*UnitError); !ok {
+				t.Errorf("ParseQuantityIn(%#v, %#v) should fail with a unit error, got %v, %v",
+					testCase.Data, testCase.Unit, res, err)
+			}
+		} else if err != nil {
+			t.Error(err)
+		} else if res != testCase.Result {
+			t.Errorf("ParseQuantityIn(%#v, %#v) should return %v, got %v",
+				testCase.Data, testCase.Unit, testCase.Result, res)
+		}
+	}
+}
+
+func TestNormalizeUnit(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{"Ω", "Ω"},
+		testCase{"ohm", "Ω"},
+		testCase{" Ohms ", "Ω"},
+		testCase{"\u2126", "Ω"},
+		testCase{"uF", "µF"},
+		testCase{"\u03bcF", "µF"},
+		testCase{"µF", "µF"},
+		testCase{"uOhm", "µΩ"},
+		testCase{"F", "F"},
+		testCase{"u", "u"},
+		testCase{"pcs", "pcs"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := NormalizeUnit(testCase.Data); res != testCase.Result {
+			t.Errorf("NormalizeUnit(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestQuantityCmp(t *testing.T) {
+	a := Quantity{Number{4.7, Kilo}, "Ω"}
+	b := Quantity{Number{10, Kilo}, "Ω"}
+
+	if res, err := a.Cmp(b); err != nil || res != -1 {
+		t.Errorf("%v.Cmp(%v) should return -1, got %v, %v", a, b, res, err)
+	}
+	if res, err := b.Cmp(a); err != nil || res != 1 {
+		t.Errorf("%v.Cmp(%v) should return 1, got %v, %v", b, a, res, err)
+	}
+	if res, err := a.Cmp(a); err != nil || res != 0 {
+		t.Errorf("%v.Cmp(%v) should return 0, got %v, %v", a, a, res, err)
+	}
+
+	c := Quantity{Number{4.7, Kilo}, "F"}
+	if _, err := a.Cmp(c); err == nil {
+		t.Errorf("%v.Cmp(%v) should fail", a, c)
+	}
+}
+
+func TestQuantityString(t *testing.T) {
+	for _, s := range []string{"4.7kΩ", "100nF", "22 pF", "3.3V", "10mA"} {
+		q, err := ParseQuantity(s)
+		if err != nil {
+			t.Error(err)
+		} else if q.String() != s {
+			t.Errorf("%#v should round-trip, got %#v", s, q.String())
+		}
+	}
+}
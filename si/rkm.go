@@ -0,0 +1,100 @@
+package si
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RKMMarkers maps the letters of the RKM code (IEC 60062) to the prefixes they
+// represent. The letter replaces the decimal point, R and E mark values
+// without prefix.
+var RKMMarkers = map[rune]Prefix{
+	'T': Tera,
+	'G': Giga,
+	'M': Mega,
+	'k': Kilo,
+	'K': Kilo,
+	'R': None,
+	'r': None,
+	'E': None,
+	'm': Milli,
+	'u': Micro,
+	'μ': Micro,
+	'µ': Micro,
+	'n': Nano,
+	'p': Pico,
+	'f': Femto,
+}
+
+// ParseRKM converts a string in RKM notation like "4k7", "2R2", "R47" or
+// "1M5" to a Number object. A missing fraction ("47k") is allowed, a missing
+// integer part ("R47") is treated as zero.
+func ParseRKM(s string) (num Number, err error) {
+	syntaxError := &strconv.NumError{Func: "ParseRKM", Num: s, Err: strconv.ErrSyntax}
+
+	pos := strings.IndexFunc(s, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if pos < 0 {
+		return num, syntaxError
+	}
+
+	marker, size := utf8.DecodeRuneInString(s[pos:])
+	prefix, ok := RKMMarkers[marker]
+	if !ok {
+		return num, syntaxError
+	}
+
+	integer, fraction := s[:pos], s[pos+size:]
+	if integer == "" && fraction == "" {
+		return num, syntaxError
+	}
+	if strings.IndexFunc(fraction, func(r rune) bool {
+		return r < '0' || r > '9'
+	}) >= 0 {
+		return num, syntaxError
+	}
+	if integer == "" {
+		integer = "0"
+	}
+
+	num.Significand, err = strconv.ParseFloat(integer+"."+fraction+"0", 64)
+	if err != nil {
+		return num, syntaxError
+	}
+	num.Exponent = prefix
+	return
+}
+
+// rkmLetters are the letters used by Number.RKM
+var rkmLetters = map[Prefix]string{
+	Tera:  "T",
+	Giga:  "G",
+	Mega:  "M",
+	Kilo:  "k",
+	None:  "R",
+	Milli: "m",
+	Micro: "u",
+	Nano:  "n",
+	Pico:  "p",
+	Femto: "f",
+}
+
+// RKM returns the RKM notation of a Number, e.g. "4k7" for 4.7 k or "2R2" for
+// 2.2. Numbers which are out of the range of the RKM letters are returned in
+// the notation of String.
+func (n Number) RKM() string {
+	n = n.Canon()
+
+	marker, ok := rkmLetters[n.Exponent]
+	if !ok {
+		return n.String()
+	}
+
+	s := strconv.FormatFloat(n.Significand, 'f', -1, 64)
+	if strings.ContainsRune(s, '.') {
+		return strings.Replace(s, ".", marker, 1)
+	}
+	return s + marker
+}
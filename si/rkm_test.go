@@ -0,0 +1,106 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestParseRKM(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Number
+	}
+
+	testCases := []testCase{
+		testCase{"4k7", Number{4.7, Kilo}},
+		testCase{"4K7", Number{4.7, Kilo}},
+		testCase{"2R2", Number{2.2, None}},
+		testCase{"R47", Number{0.47, None}},
+		testCase{"470R", Number{470, None}},
+		testCase{"6E8", Number{6.8, None}},
+		testCase{"1M5", Number{1.5, Mega}},
+		testCase{"4n7", Number{4.7, Nano}},
+		testCase{"4u7", Number{4.7, Micro}},
+		testCase{"4μ7", Number{4.7, Micro}},
+		testCase{"2p2", Number{2.2, Pico}},
+		testCase{"3m3", Number{3.3, Milli}},
+		testCase{"47k", Number{47, Kilo}},
+		testCase{"1G", Number{1, Giga}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseRKM(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res != testCase.Result {
+			t.Errorf("ParseRKM(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+
+	for _, data := range []string{"", "47", "k", "4x7", "4k7k", "4.7k"} {
+		if res, err := ParseRKM(data); err == nil {
+			t.Errorf("ParseRKM(%#v) should fail, got %#v", data, res)
+		}
+	}
+}
+
+func TestParseWithRKM(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Number
+	}
+
+	testCases := []testCase{
+		testCase{"4k7", Number{4.7, Kilo}},
+		testCase{"2R2", Number{2.2, None}},
+		testCase{"4n7", Number{4.7, Nano}},
+		testCase{"4p7", Number{4.7, Pico}},
+		testCase{"6E8", Number{6e8, None}},
+		testCase{"4.7k", Number{4.7, Kilo}},
+		testCase{"4K7", Number{4.7, Kilo}},
+		testCase{"1.5K", Number{1.5, Kilo}},
+		testCase{"1.5 K", Number{1.5, Kilo}},
+		testCase{"47K", Number{47, Kilo}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := Parse(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res != testCase.Result {
+			t.Errorf("Parse(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestRKM(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{Number{4.7, Kilo}, "4k7"},
+		testCase{Number{4700, None}, "4k7"},
+		testCase{Number{2.2, None}, "2R2"},
+		testCase{Number{470, None}, "470R"},
+		testCase{Number{100, Nano}, "100n"},
+		testCase{Number{1.5, Mega}, "1M5"},
+		testCase{Number{4.7, Micro}, "4u7"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res := testCase.Data.RKM()
+		if res != testCase.Result {
+			t.Errorf("%#v.RKM() should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
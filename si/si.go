@@ -4,8 +4,10 @@
 package si
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -14,28 +16,39 @@ import (
 type Prefix int
 
 const (
-	Yotta Prefix = 24
-	Zetta        = 21
-	Exa          = 18
-	Peta         = 15
-	Tera         = 12
-	Giga         = 9
-	Mega         = 6
-	Kilo         = 3
-	Hecto        = 2
-	Deca         = 1
-	None         = 0
-	Deci         = -1
-	Centi        = -2
-	Milli        = -3
-	Micro        = -6
-	Nano         = -9
-	Pico         = -12
-	Femto        = -15
+	Quetta Prefix = 30
+	Ronna         = 27
+	Yotta         = 24
+	Zetta         = 21
+	Exa           = 18
+	Peta          = 15
+	Tera          = 12
+	Giga          = 9
+	Mega          = 6
+	Kilo          = 3
+	Hecto         = 2
+	Deca          = 1
+	None          = 0
+	Deci          = -1
+	Centi         = -2
+	Milli         = -3
+	Micro         = -6
+	Nano          = -9
+	Pico          = -12
+	Femto         = -15
+	Atto          = -18
+	Zepto         = -21
+	Yocto         = -24
+	Ronto         = -27
+	Quecto        = -30
 )
 
 func (p Prefix) String() string {
 	switch p {
+	case Quetta:
+		return "Q"
+	case Ronna:
+		return "R"
 	case Yotta:
 		return "Y"
 	case Zetta:
@@ -70,12 +83,24 @@ func (p Prefix) String() string {
 		return "p"
 	case Femto:
 		return "f"
+	case Atto:
+		return "a"
+	case Zepto:
+		return "z"
+	case Yocto:
+		return "y"
+	case Ronto:
+		return "r"
+	case Quecto:
+		return "q"
 	}
 	return ""
 }
 
-// Prefixes is a list of common SI prefixes
+// Prefixes is the list of all SI prefixes
 var Prefixes = []Prefix{
+	Quetta,
+	Ronna,
 	Yotta,
 	Zetta,
 	Exa,
@@ -93,9 +118,16 @@ var Prefixes = []Prefix{
 	Nano,
 	Pico,
 	Femto,
+	Atto,
+	Zepto,
+	Yocto,
+	Ronto,
+	Quecto,
 }
 
 var CanonPrefixes = []Prefix{
+	Quetta,
+	Ronna,
 	Yotta,
 	Zetta,
 	Exa,
@@ -110,12 +142,22 @@ var CanonPrefixes = []Prefix{
 	Nano,
 	Pico,
 	Femto,
+	Atto,
+	Zepto,
+	Yocto,
+	Ronto,
+	Quecto,
 }
 
 var PrefixMapping map[string]Prefix
 
+// prefixStrings contains the keys of PrefixMapping, longest first
+var prefixStrings []string
+
 // A Number is a float64 combined with a Exponent, it is similar to a decimal
-// floating number with the restriction that it imposes additional inaccuracy
+// floating number with the restriction that it imposes additional inaccuracy.
+// Values are scaled in decimal, so Number{4.7, Pico} has the same value as
+// the float64 literal 4.7e-12.
 type Number struct {
 	Significand float64
 	Exponent    Prefix
@@ -135,46 +177,161 @@ func New(val float64) Number {
 // looks for a prefix. You can use one space (' ') between the floating point
 // number and the prefix string. Please note that the space is required when
 // the input contains the "E" or "p" prefix.
+//
+// Parse also accepts the RKM notation of ParseRKM, like "4k7". Strings which
+// are valid floating point numbers are never treated as RKM, so "6E8" is
+// 6*10^8 and not 6.8.
+//
+// A comma is accepted as decimal separator, as long as there is no dot. A
+// comma followed by exactly three digits, like in "1,000", might also be a
+// thousands separator, so it results in ErrAmbiguousComma unless the integer
+// part is zero.
+//
+// A trailing "R" or "r" is read as RKM marker, so "4.7R" is 4.7. The prefixes
+// ronna and ronto require the space, like in "4.7 R".
+//
+// Unknown prefixes result in an *UnknownPrefixError, so "10x" is not
+// silently read as 10.
 func Parse(s string) (num Number, err error) {
+	if !strings.ContainsRune(s, '.') {
+		if ambiguousComma(s) {
+			return num, ErrAmbiguousComma
+		}
+		s = strings.Replace(s, ",", ".", 1)
+	}
 	if strings.ContainsRune(s, ' ') {
 		numberPrefix := strings.SplitN(s, " ", 2)
-		if len(numberPrefix) == 2 {
-			num.Exponent = PrefixMapping[numberPrefix[1]]
-			s = numberPrefix[0]
+		prefix, ok := PrefixMapping[numberPrefix[1]]
+		if !ok && numberPrefix[1] != "" {
+			return num, &UnknownPrefixError{Num: s, Prefix: numberPrefix[1]}
 		}
+		num.Significand, err = strconv.ParseFloat(numberPrefix[0], 64)
+		num.Exponent = prefix
+		return
 	}
 	num.Significand, err = strconv.ParseFloat(s, 64)
 	if err == nil {
 		return
 	}
+	if rkm, err := ParseRKM(s); err == nil {
+		return rkm, nil
+	}
+	for _, p := range prefixStrings {
+		if strings.HasSuffix(s, p) {
+			num.Significand, err = strconv.ParseFloat(s[:len(s)-len(p)], 64)
+			if err == nil {
+				num.Exponent = PrefixMapping[p]
+				if p == "R" || p == "r" {
+					num.Exponent = None
+				}
+				return
+			}
+		}
+	}
 	var p string
 	_, err = fmt.Sscanf(s, "%f%s", &num.Significand, &p)
 	if err != nil {
 		return
 	}
-	num.Exponent = PrefixMapping[p]
-	return
+	return Number{}, &UnknownPrefixError{Num: s, Prefix: p}
+}
+
+// ErrAmbiguousComma is returned by Parse for numbers like "1,000", where the
+// comma might be a decimal or a thousands separator
+var ErrAmbiguousComma = errors.New("si: ambiguous comma, use a dot as decimal separator")
+
+// ambiguousComma reports whether s has a comma followed by exactly three
+// digits after a non-zero integer part
+func ambiguousComma(s string) bool {
+	pos := strings.IndexByte(s, ',')
+	if pos < 0 {
+		return false
+	}
+	integer := strings.TrimLeft(s[:pos], "+-")
+	if strings.Trim(integer, "0") == "" || strings.IndexFunc(integer, notDigit) >= 0 {
+		return false
+	}
+	fraction := s[pos+1:]
+	if end := strings.IndexFunc(fraction, notDigit); end >= 0 {
+		fraction = fraction[:end]
+	}
+	return len(fraction) == 3
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+// DecimalDigits is the number of significant digits kept by Canon and Decimal,
+// which is the precision of a float64 without rounding artifacts
+const DecimalDigits = 15
+
+// scale returns val * 10^exp. The multiplication is done on the decimal
+// representation, because multiplying with math.Pow10 is inexact for negative
+// exponents: 4.7 * 1e-12 is not 4.7e-12.
+func scale(val float64, exp int) float64 {
+	if exp == 0 || val == 0 || math.IsInf(val, 0) || math.IsNaN(val) {
+		return val
+	}
+	s := strconv.FormatFloat(val, 'e', -1, 64)
+	mantissa, valExp := s, 0
+	if pos := strings.IndexByte(s, 'e'); pos >= 0 {
+		mantissa = s[:pos]
+		valExp, _ = strconv.Atoi(s[pos+1:])
+	}
+	res, _ := strconv.ParseFloat(mantissa+"e"+strconv.Itoa(valExp+exp), 64)
+	return res
+}
+
+// round returns val rounded to DecimalDigits significant digits
+func round(val float64) float64 {
+	res, _ := strconv.ParseFloat(strconv.FormatFloat(val, 'e', DecimalDigits-1, 64), 64)
+	return res
+}
+
+// An UnknownPrefixError is returned by Parse, when a number is followed by
+// something which is not a SI prefix
+type UnknownPrefixError struct {
+	Num    string
+	Prefix string
+}
+
+func (e *UnknownPrefixError) Error() string {
+	return fmt.Sprintf("si: unknown prefix %q in %q", e.Prefix, e.Num)
 }
 
 // Value returns the real value of a Number object as float64
 func (n Number) Value() float64 {
-	return n.Significand * math.Pow10(int(n.Exponent))
+	return scale(n.Significand, int(n.Exponent))
+}
+
+// Decimal returns the normalised decimal representation of the value, rounded
+// to DecimalDigits significant digits, like "4.7e-12" or "4700". Two numbers
+// have the same value if their decimal representations are equal. The format
+// is the one of printf("%.15g") in C and SQLite.
+func (n Number) Decimal() string {
+	return fmt.Sprintf("%."+strconv.Itoa(DecimalDigits)+"g", n.Value())
 }
 
-// String returns a string representation of a Number
+// String returns a string representation of a Number. The prefixes "E" and
+// "p" are separated by a space, so the result can be read by Parse.
 func (n Number) String() string {
-	if n.Exponent == 0 {
-		return strconv.FormatFloat(n.Significand, 'f', -1, 64)
+	s := strconv.FormatFloat(n.Significand, 'f', -1, 64)
+	switch n.Exponent {
+	case None:
+		return s
+	case Exa, Pico:
+		return s + " " + n.Exponent.String()
 	}
-	return strconv.FormatFloat(n.Significand, 'f', -1, 64) + " " + n.Exponent.String()
+	return s + n.Exponent.String()
 }
 
 // Canon tries to find the best matching SI prefix for a Value and returns a
 // new Number object holding that
 func (n Number) Canon() Number {
-	val := n.Value()
+	val := round(n.Value())
 	for _, prefix := range CanonPrefixes {
-		sig := val / math.Pow10(int(prefix))
+		sig := scale(val, -int(prefix))
 		if sig >= 1.0 && sig <= 1000 {
 			return Number{
 				Significand: sig,
@@ -191,4 +348,18 @@ func init() {
 		PrefixMapping[prefix.String()] = prefix
 	}
 	PrefixMapping["u"] = Micro
+	PrefixMapping["µ"] = Micro // MICRO SIGN
+	// The upper-case kilo is common in BOMs, kelvin is never a prefix
+	PrefixMapping["K"] = Kilo
+
+	for p := range PrefixMapping {
+		prefixStrings = append(prefixStrings, p)
+	}
+	sort.Sort(byLength(prefixStrings))
 }
+
+type byLength []string
+
+func (s byLength) Len() int           { return len(s) }
+func (s byLength) Less(i, j int) bool { return len(s[i]) > len(s[j]) }
+func (s byLength) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -1,6 +1,7 @@
 package si
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -11,6 +12,8 @@ func TestParse(t *testing.T) {
 	}
 
 	testCases := []testCase{
+		testCase{"1.0Q", Number{1, Quetta}},
+		testCase{"1.0 R", Number{1, Ronna}},
 		testCase{"1.0Y", Number{1, Yotta}},
 		testCase{"1.0Z", Number{1, Zetta}},
 		testCase{"1.0 E", Number{1, Exa}},
@@ -29,6 +32,13 @@ func TestParse(t *testing.T) {
 		testCase{"1.0n", Number{1, Nano}},
 		testCase{"1.0 p", Number{1, Pico}},
 		testCase{"1.0f", Number{1, Femto}},
+		testCase{"1.0a", Number{1, Atto}},
+		testCase{"1.0z", Number{1, Zepto}},
+		testCase{"1.0y", Number{1, Yocto}},
+		testCase{"1.0 r", Number{1, Ronto}},
+		testCase{"1.0q", Number{1, Quecto}},
+		testCase{"4.7R", Number{4.7, None}},
+		testCase{"10 ", Number{10, None}},
 	}
 
 	for _, testCase := range testCases {
@@ -44,6 +54,47 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseAmbiguousComma(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Number
+		Err    error
+	}
+
+	testCases := []testCase{
+		testCase{"4,7", Number{4.7, None}, nil},
+		testCase{"4,7k", Number{4.7, Kilo}, nil},
+		testCase{"0,125", Number{0.125, None}, nil},
+		testCase{"1,0005", Number{1.0005, None}, nil},
+		testCase{"1,000", Number{}, ErrAmbiguousComma},
+		testCase{"4,700k", Number{}, ErrAmbiguousComma},
+		testCase{"-2,500", Number{}, ErrAmbiguousComma},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := Parse(testCase.Data)
+		if err != testCase.Err {
+			t.Errorf("Parse(%#v) should fail with %v, got %v", testCase.Data,
+				testCase.Err, err)
+		} else if res != testCase.Result {
+			t.Errorf("Parse(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestParseUnknownPrefix(t *testing.T) {
+	for _, data := range []string{"10x", "1.0 X", "4.7kOhm", "10 kk"} {
+		res, err := Parse(data)
+		if _, ok := err.(*UnknownPrefixError); !ok {
+			t.Errorf("Parse(%#v) should fail with an unknown prefix, got %#v, %v",
+				data, res, err)
+		}
+	}
+}
+
 func TestString(t *testing.T) {
 	type testCase struct {
 		Data   Number
@@ -69,6 +120,9 @@ func TestString(t *testing.T) {
 		testCase{Number{1, Nano}, "1n"},
 		testCase{Number{1, Pico}, "1 p"},
 		testCase{Number{1, Femto}, "1f"},
+		testCase{Number{1, Atto}, "1a"},
+		testCase{Number{1, Quecto}, "1q"},
+		testCase{Number{1, Quetta}, "1Q"},
 	}
 
 	for _, testCase := range testCases {
@@ -82,3 +136,66 @@ func TestString(t *testing.T) {
 		}
 	}
 }
+
+func TestValue(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Result float64
+	}
+
+	testCases := []testCase{
+		testCase{Number{4.7, Pico}, 4.7e-12},
+		testCase{Number{0.1, Micro}, 1e-7},
+		testCase{Number{100, Nano}, 1e-7},
+		testCase{Number{3.3, Nano}, 3.3e-9},
+		testCase{Number{4.7, Kilo}, 4700},
+		testCase{Number{2.2, Mega}, 2.2e6},
+		testCase{Number{0, Pico}, 0},
+		testCase{Number{-4.7, Milli}, -4.7e-3},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := testCase.Data.Value(); res != testCase.Result {
+			t.Errorf("%#v.Value() should return %v, got %v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	type testCase struct {
+		Data   Number
+		Result string
+	}
+
+	testCases := []testCase{
+		testCase{Number{4.7, Pico}, "4.7e-12"},
+		testCase{Number{4700, Femto}, "4.7e-12"},
+		testCase{New(4.699999999999999e-12), "4.7e-12"},
+		testCase{Number{0.1, Micro}, "1e-07"},
+		testCase{Number{4.7, Kilo}, "4700"},
+		testCase{Number{1, Mega}, "1000000"},
+		testCase{Number{1, Exa}, "1e+18"},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if res := testCase.Data.Decimal(); res != testCase.Result {
+			t.Errorf("%#v.Decimal() should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+}
+
+func TestCanonArtifacts(t *testing.T) {
+	for _, val := range []float64{4.7e-12, 4.699999999999999e-12, 1e-7,
+		0.1 * 1e-6, 3.3e-9, 4.7 * 1e3} {
+		res := New(val).Canon().String()
+		if strings.Contains(res, "99999") || strings.Contains(res, "00000") {
+			t.Errorf("New(%v).Canon() has rounding artifacts: %v", val, res)
+		}
+	}
+}
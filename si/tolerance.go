@@ -0,0 +1,117 @@
+package si
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNegativeTolerance is returned by ParseTolerance for negative tolerances
+var ErrNegativeTolerance = errors.New("si: negative tolerance")
+
+// A Tolerance is either relative, given in percent, or an absolute deviation
+// like 0.25 pF. The zero value is no tolerance at all.
+type Tolerance struct {
+	// Percent is the relative tolerance, it is only used if Absolute is zero
+	Percent  float64
+	Absolute Number
+}
+
+// A Band is the closed interval of values covered by a toleranced value
+type Band struct {
+	Low  float64
+	High float64
+}
+
+// tolerancePrefixes are the accepted notations of plus-minus
+var tolerancePrefixes = []string{"±", "+/-", "+-"}
+
+// ParseTolerance converts a string like "5%", "±1%" or "±0.25p" to a
+// Tolerance
+func ParseTolerance(s string) (t Tolerance, err error) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range tolerancePrefixes {
+		s = strings.TrimSpace(strings.TrimPrefix(s, prefix))
+	}
+
+	if strings.HasSuffix(s, "%") {
+		s = strings.TrimSpace(s[:len(s)-1])
+		if !strings.ContainsRune(s, '.') {
+			s = strings.Replace(s, ",", ".", 1)
+		}
+		t.Percent, err = strconv.ParseFloat(s, 64)
+	} else {
+		t.Absolute, err = Parse(s)
+	}
+	if err == nil && (t.Percent < 0 || t.Absolute.Value() < 0) {
+		err = ErrNegativeTolerance
+	}
+	return
+}
+
+// SplitTolerance splits a string like "10k±5%" or "10k 5%" into the value and
+// the tolerance. The tolerance is empty if there is none.
+func SplitTolerance(s string) (value, tolerance string) {
+	s = strings.TrimSpace(s)
+	for _, prefix := range tolerancePrefixes {
+		if pos := strings.Index(s, prefix); pos > 0 {
+			return strings.TrimSpace(s[:pos]), s[pos:]
+		}
+	}
+	if pos := strings.LastIndex(s, " "); pos > 0 && strings.HasSuffix(s, "%") {
+		return strings.TrimSpace(s[:pos]), s[pos+1:]
+	}
+	return s, ""
+}
+
+// ParseToleranced parses a value with an optional tolerance like "10k±5%"
+func ParseToleranced(s string) (n Number, t Tolerance, err error) {
+	value, tolerance := SplitTolerance(s)
+	n, err = Parse(value)
+	if err == nil && tolerance != "" {
+		t, err = ParseTolerance(tolerance)
+	}
+	return
+}
+
+// IsZero reports whether t is no tolerance at all
+func (t Tolerance) IsZero() bool {
+	return t.Percent == 0 && t.Absolute.Value() == 0
+}
+
+// String returns a representation of the tolerance which can be read by
+// ParseTolerance, like "5%" or "0.25 p"
+func (t Tolerance) String() string {
+	if t.Absolute.Value() != 0 {
+		return t.Absolute.Canon().String()
+	}
+	return strconv.FormatFloat(t.Percent, 'f', -1, 64) + "%"
+}
+
+// Deviation returns the absolute deviation allowed for the value of n
+func (t Tolerance) Deviation(n Number) float64 {
+	if t.Absolute.Value() != 0 {
+		return t.Absolute.Value()
+	}
+	return math.Abs(n.Value()) * t.Percent / 100
+}
+
+// Band returns the values covered by n with the tolerance t
+func (t Tolerance) Band(n Number) Band {
+	val, dev := n.Value(), t.Deviation(n)
+	return Band{val - dev, val + dev}
+}
+
+// Contains reports whether the band o lies within b, i.e. whether a part with
+// the band o satisfies a requirement of b
+func (b Band) Contains(o Band) bool {
+	return o.Low >= b.Low && o.High <= b.High
+}
+
+// Intersect returns the values which are covered by both bands. The result
+// is false if the bands are disjoint.
+func (b Band) Intersect(o Band) (Band, bool) {
+	res := Band{math.Max(b.Low, o.Low), math.Min(b.High, o.High)}
+	return res, res.Low <= res.High
+}
@@ -0,0 +1,93 @@
+package si
+
+import (
+	"testing"
+)
+
+func TestParseTolerance(t *testing.T) {
+	type testCase struct {
+		Data   string
+		Result Tolerance
+	}
+
+	testCases := []testCase{
+		testCase{"5%", Tolerance{Percent: 5}},
+		testCase{"±1%", Tolerance{Percent: 1}},
+		testCase{"+/- 0.1 %", Tolerance{Percent: 0.1}},
+		testCase{"0,5%", Tolerance{Percent: 0.5}},
+		testCase{"±0.25 p", Tolerance{Absolute: Number{0.25, Pico}}},
+		testCase{"10m", Tolerance{Absolute: Number{10, Milli}}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		res, err := ParseTolerance(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if res != testCase.Result {
+			t.Errorf("ParseTolerance(%#v) should return %#v, got %#v", testCase.Data,
+				testCase.Result, res)
+		}
+	}
+
+	for _, data := range []string{"-5%", "x%", "5x"} {
+		if res, err := ParseTolerance(data); err == nil {
+			t.Errorf("ParseTolerance(%#v) should fail, got %#v", data, res)
+		}
+	}
+}
+
+func TestParseToleranced(t *testing.T) {
+	type testCase struct {
+		Data      string
+		Value     float64
+		Tolerance Tolerance
+	}
+
+	testCases := []testCase{
+		testCase{"10k±5%", 10000, Tolerance{Percent: 5}},
+		testCase{"10k 1%", 10000, Tolerance{Percent: 1}},
+		testCase{"4.7 p +/- 0.25 p", 4.7e-12, Tolerance{Absolute: Number{0.25, Pico}}},
+		testCase{"4k7", 4700, Tolerance{}},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		n, tol, err := ParseToleranced(testCase.Data)
+		if err != nil {
+			t.Error(err)
+		} else if n.Value() != testCase.Value || tol != testCase.Tolerance {
+			t.Errorf("ParseToleranced(%#v) should return %v, %#v, got %v, %#v",
+				testCase.Data, testCase.Value, testCase.Tolerance, n.Value(), tol)
+		}
+	}
+}
+
+func TestBand(t *testing.T) {
+	value := Number{10, Kilo}
+	precise := Tolerance{Percent: 1}.Band(value)
+	coarse := Tolerance{Percent: 5}.Band(value)
+
+	if precise != (Band{9900, 10100}) {
+		t.Errorf("1%% band of 10k should be 9900-10100, got %v", precise)
+	}
+	if !coarse.Contains(precise) {
+		t.Errorf("a 1%% part should satisfy a 5%% requirement")
+	}
+	if precise.Contains(coarse) {
+		t.Errorf("a 5%% part should not satisfy a 1%% requirement")
+	}
+	if !coarse.Contains(coarse) {
+		t.Errorf("a band should contain itself")
+	}
+
+	res, ok := precise.Intersect(Tolerance{Percent: 1}.Band(Number{10.1, Kilo}))
+	if !ok || res.Low != 9999 || res.High != 10100 {
+		t.Errorf("intersection should be 9999-10100, got %v, %v", res, ok)
+	}
+	if _, ok := precise.Intersect(Tolerance{Percent: 1}.Band(Number{11, Kilo})); ok {
+		t.Errorf("bands of 10k and 11k with 1%% should be disjoint")
+	}
+}
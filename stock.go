@@ -0,0 +1,79 @@
+package inventory
+
+import (
+	"database/sql"
+	"io"
+	"strconv"
+
+	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultStockUnit is the unit of measure of parts without stock_unit
+const DefaultStockUnit = "pcs"
+
+// StockUnits are the units of measure which can be chosen for the stock of a
+// part
+var StockUnits = []string{"pcs", "m", "g", "ml"}
+
+// stockUnit returns the unit of measure of a stock_unit column
+func stockUnit(unit sql.NullString) string {
+	if !unit.Valid || unit.String == "" {
+		return DefaultStockUnit
+	}
+	return unit.String
+}
+
+func isStockUnit(unit string) bool {
+	for _, u := range StockUnits {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAmount parses a stock amount in the given unit of measure, e.g. "250m"
+// or "2.5" for metres or "1.5k" for pieces
+func ParseAmount(s string, unit string) (float64, error) {
+	if unit == DefaultStockUnit {
+		unit = ""
+	}
+	q, err := si.ParseQuantityIn(s, unit)
+	if err == io.EOF {
+		err = nil
+	}
+	return q.Value(), err
+}
+
+// formatAmount formats a stock amount without exponent
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// formatStock formats a stock amount with its unit of measure, which is
+// omitted for pieces
+func formatStock(amount float64, unit sql.NullString) string {
+	s := formatAmount(amount)
+	if u := stockUnit(unit); u != DefaultStockUnit {
+		s += " " + u
+	}
+	return s
+}
+
+// stockTotal is the sum of the stock of all parts with the same unit of
+// measure
+type stockTotal struct {
+	Unit   string  `db:"unit"`
+	Amount float64 `db:"amount"`
+}
+
+// stockTotals sums the stock of all parts, grouped by unit of measure
+func stockTotals(db sqlx.Queryer) ([]stockTotal, error) {
+	totals := []stockTotal{}
+	err := sqlx.Select(db, &totals, `SELECT IFNULL("stock_unit", ?) AS 'unit',
+	IFNULL(SUM("amount"), 0) AS 'amount' FROM 'part_view'
+	GROUP BY IFNULL("stock_unit", ?) ORDER BY "unit" ASC`,
+		DefaultStockUnit, DefaultStockUnit)
+	return totals, err
+}
@@ -0,0 +1,38 @@
+package inventory
+
+import (
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	type testCase struct {
+		Input  string
+		Unit   string
+		Amount float64
+		Error  bool
+	}
+
+	testCases := []testCase{
+		testCase{"", DefaultStockUnit, 0, false},
+		testCase{"12", DefaultStockUnit, 12, false},
+		testCase{"1.5k", DefaultStockUnit, 1500, false},
+		testCase{"2.5", "m", 2.5, false},
+		testCase{"250m", "m", 250, false},
+		testCase{"250mm", "m", 0.25, false},
+		testCase{"1kg", "g", 1000, false},
+		testCase{"ten", DefaultStockUnit, 0, true},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		amount, err := ParseAmount(testCase.Input, testCase.Unit)
+		if (err != nil) != testCase.Error {
+			t.Errorf("Got error %v", err)
+			continue
+		}
+		if !testCase.Error && amount != testCase.Amount {
+			t.Errorf("Got %v", amount)
+		}
+	}
+}
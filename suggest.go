@@ -0,0 +1,157 @@
+package inventory
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/fritz0705/inventory/si"
+)
+
+// SuggestionLimit is the maximum number of suggestions per kind returned by
+// SuggestHandler
+var SuggestionLimit = 8
+
+type partSuggestion struct {
+	Id         int64           `json:"id" db:"id"`
+	Name       string          `json:"name" db:"name"`
+	Value      sql.NullFloat64 `json:"-" db:"value"`
+	UnitSymbol sql.NullString  `json:"-" db:"unit_symbol"`
+	Prefix     sql.NullInt64   `json:"-" db:"display_prefix"`
+	PlaceName  sql.NullString  `json:"-" db:"place_name"`
+	Amount     sql.NullFloat64 `json:"-" db:"amount"`
+	StockUnit  sql.NullString  `json:"-" db:"stock_unit"`
+
+	Canon string  `json:"value"`
+	Stock float64 `json:"stock"`
+	Unit  string  `json:"unit"`
+	Place string  `json:"place"`
+	URL   string  `json:"url"`
+}
+
+type nameSuggestion struct {
+	Id   int64  `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+	URL  string `json:"url"`
+}
+
+type suggestions struct {
+	Parts      []partSuggestion `json:"parts"`
+	Places     []nameSuggestion `json:"places"`
+	Categories []nameSuggestion `json:"categories"`
+}
+
+// prefixRange returns the bounds of all strings starting with prefix. Used
+// with BETWEEN on a NOCASE index it is much faster than LIKE, which SQLite
+// only optimizes under special circumstances.
+func prefixRange(prefix string) (string, string) {
+	return prefix, prefix + "\uffff"
+}
+
+func containsSuggestion(parts []partSuggestion, id int64) bool {
+	for _, part := range parts {
+		if part.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// The amount is selected with a correlated subquery instead of using
+// part_view, so only the matching parts have to be looked up.
+const partSuggestionQuery = `SELECT 'part'."id" AS 'id', 'part'."name" AS 'name',
+	'part'."value" AS 'value', 'category'."unit_symbol" AS 'unit_symbol',
+	'category'."display_prefix" AS 'display_prefix',
+	'place'."name" AS 'place_name', 'part'."stock_unit" AS 'stock_unit',
+	(SELECT "amount" FROM 'part_amount' WHERE "part_id" = 'part'."id"
+		ORDER BY "timestamp" DESC LIMIT 1) AS 'amount'
+	FROM 'part'
+	LEFT JOIN 'category' ON 'category'."id" = 'part'."category_id"
+	LEFT JOIN 'place' ON 'place'."id" = 'part'."place_id"`
+
+func (app *Application) SuggestHandler(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.FormValue("q"))
+	res := suggestions{
+		Parts:      []partSuggestion{},
+		Places:     []nameSuggestion{},
+		Categories: []nameSuggestion{},
+	}
+
+	if prefix != "" {
+		low, high := prefixRange(prefix)
+
+		tx := app.DB.MustBegin()
+		defer tx.Rollback()
+
+		err := tx.Select(&res.Parts, partSuggestionQuery+`
+		WHERE 'part'."name" COLLATE NOCASE BETWEEN ? AND ?
+		ORDER BY 'part'."name" COLLATE NOCASE LIMIT ?`, low, high, SuggestionLimit)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+
+		// Values like "4.7k" are suggested too, as long as there is room left
+		if num, err := si.Parse(prefix); err == nil && num.Value() != 0 &&
+			len(res.Parts) < SuggestionLimit {
+			byValue := []partSuggestion{}
+			err = tx.Select(&byValue, partSuggestionQuery+`
+			WHERE 'part'."value_decimal" = ? ORDER BY 'part'."name" LIMIT ?`, num.Decimal(),
+				SuggestionLimit-len(res.Parts))
+			if err != nil {
+				app.Error(w, err)
+				return
+			}
+			for _, part := range byValue {
+				if !containsSuggestion(res.Parts, part.Id) {
+					res.Parts = append(res.Parts, part)
+				}
+			}
+		}
+
+		err = tx.Select(&res.Places, `SELECT "id", "name" FROM 'place'
+		WHERE "name" COLLATE NOCASE BETWEEN ? AND ?
+		ORDER BY "name" COLLATE NOCASE LIMIT ?`, low, high, SuggestionLimit)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+
+		err = tx.Select(&res.Categories, `SELECT "id", "name" FROM 'category'
+		WHERE "name" COLLATE NOCASE BETWEEN ? AND ?
+		ORDER BY "name" COLLATE NOCASE LIMIT ?`, low, high, SuggestionLimit)
+		if err != nil {
+			app.Error(w, err)
+			return
+		}
+
+		tx.Commit()
+	}
+
+	for n := range res.Parts {
+		part := &res.Parts[n]
+		if part.Value.Valid {
+			part.Canon = formatValue(app.NumberFormat, part.Value.Float64,
+				part.Prefix) + part.UnitSymbol.String
+		}
+		part.Stock = part.Amount.Float64
+		part.Unit = stockUnit(part.StockUnit)
+		part.Place = part.PlaceName.String
+		part.URL = fmt.Sprintf("/parts/edit/%d", part.Id)
+	}
+	for n := range res.Places {
+		res.Places[n].URL = fmt.Sprintf("/parts?place=%d", res.Places[n].Id)
+	}
+	for n := range res.Categories {
+		res.Categories[n].URL = fmt.Sprintf("/parts?category=%d", res.Categories[n].Id)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"html/template"
 	"io"
+	"log"
 	"net/http"
 
 	"github.com/fritz0705/inventory/si"
@@ -40,11 +41,17 @@ func (a *Application) renderTemplate(w http.ResponseWriter, r *http.Request, dat
 		data = make(map[string]struct{})
 	}
 
-	if a.Sessions != nil {
-		session, err := a.Sessions.Get(r, a.SessionName)
-		if err == nil && session.Values["userId"] != nil {
-			params["User"] = session.Values["userId"]
+	userId, ok := a.currentUserId(r)
+	if ok {
+		params["User"] = userId
+	}
+
+	if a.DB != nil {
+		views, err := a.navbarViews(a.DB, userId)
+		if err != nil {
+			log.Print(err)
 		}
+		params["Views"] = views
 	}
 
 	err := a.renderWithLayout(w, params, data, templates...)
@@ -53,11 +60,37 @@ func (a *Application) renderTemplate(w http.ResponseWriter, r *http.Request, dat
 	}
 }
 
+// formatValue formats a part value, prefix is the display prefix of the
+// part's category
+func formatValue(format si.Format, num float64, prefix sql.NullInt64) string {
+	if prefix.Valid {
+		format = format.WithPrefix(si.Prefix(prefix.Int64))
+	}
+	return si.New(num).Format(format)
+}
+
+// numberFuncs returns the template functions which format numbers with the
+// NumberFormat of the application
+func (a *Application) numberFuncs() template.FuncMap {
+	return template.FuncMap{
+		"siCanon": func(num float64) string {
+			return si.New(num).Format(a.NumberFormat)
+		},
+		"siFormat": func(num float64, prefix sql.NullInt64) string {
+			return formatValue(a.NumberFormat, num, prefix)
+		},
+	}
+}
+
 var templateFuncs = template.FuncMap{
 	"siCanon": func(num float64) string {
 		return si.New(num).Canon().String()
 	},
 
+	"siFormat": func(num float64, prefix sql.NullInt64) string {
+		return formatValue(si.Format{}, num, prefix)
+	},
+
 	"unnull": func(v interface{}) interface{} {
 		switch i := v.(type) {
 		case sql.NullInt64:
@@ -82,6 +115,9 @@ var templateFuncs = template.FuncMap{
 
 		return nil
 	},
+	"amount":    formatAmount,
+	"stock":     formatStock,
+	"stockUnit": stockUnit,
 	"hex": func(v []byte) string {
 		return hex.EncodeToString(v)
 	},
@@ -0,0 +1,328 @@
+package inventory
+
+import (
+	"database/sql"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fritz0705/inventory/importer"
+	"github.com/fritz0705/inventory/si"
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultImportCategory is the category of imported parts without category
+const DefaultImportCategory = "Imported"
+
+// An InventoryImport summarises the import of an importer.Inventory
+type InventoryImport struct {
+	Parts       int
+	Attachments int
+	// Matched are the names of the existing parts which were updated
+	Matched []string
+	// Categories and Places are the names of the created categories and
+	// places
+	Categories []string
+	Places     []string
+	Problems   []importer.Problem
+	// Blobs are the keys of the stored attachments, they have to be deleted
+	// if the import is not committed
+	Blobs [][]byte
+}
+
+type inventoryImporter struct {
+	tx     *sqlx.Tx
+	store  AttachmentStore
+	files  string
+	result *InventoryImport
+	// categories maps the joined category paths to ids, places and
+	// distributors map lower-case names to ids
+	categories   map[string]int64
+	places       map[string]int64
+	distributors map[string]int64
+}
+
+// ImportInventory creates the parts of an inventory read by the importer
+// package, existing parts are matched like by ImportCSV and updated.
+// Categories are matched by their path and places by their name, both are
+// created if needed. Attachment files are read from the directory files,
+// they are skipped if store is nil. Problems of the inventory and of the
+// import are collected in the result.
+func ImportInventory(tx *sqlx.Tx, store AttachmentStore, inv *importer.Inventory, files string) (*InventoryImport, error) {
+	im := &inventoryImporter{
+		tx:    tx,
+		store: store,
+		files: files,
+		result: &InventoryImport{
+			Problems: append([]importer.Problem{}, inv.Problems...),
+		},
+		categories: make(map[string]int64),
+	}
+
+	var err error
+	im.places, err = namedIds(tx, "place")
+	if err == nil {
+		im.distributors, err = namedIds(tx, "distributor")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, part := range inv.Parts {
+		if err := im.importPart(part); err != nil {
+			return im.result, err
+		}
+	}
+	return im.result, nil
+}
+
+func (im *inventoryImporter) problem(item, reason string) {
+	im.result.Problems = append(im.result.Problems, importer.Problem{Item: item, Reason: reason})
+}
+
+// category returns the id of the category path, it creates missing
+// categories
+func (im *inventoryImporter) category(names []string) (int64, error) {
+	if len(names) == 0 {
+		names = []string{DefaultImportCategory}
+	}
+
+	var parent sql.NullInt64
+	for n, name := range names {
+		key := strings.ToLower(strings.Join(names[:n+1], "\x00"))
+		if id, ok := im.categories[key]; ok {
+			parent = sql.NullInt64{Int64: id, Valid: true}
+			continue
+		}
+
+		category := new(Category)
+		err := im.tx.Get(category, `SELECT * FROM 'category' WHERE "name" = ?
+		AND "parent_id" IS ? LIMIT 1`, name, parent)
+		if err == sql.ErrNoRows {
+			category = &Category{Name: name, ParentId: parent}
+			err = category.Save(im.tx)
+			im.result.Categories = append(im.result.Categories,
+				strings.Join(names[:n+1], " / "))
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		im.categories[key] = category.Id
+		parent = sql.NullInt64{Int64: category.Id, Valid: true}
+	}
+	return parent.Int64, nil
+}
+
+func (im *inventoryImporter) place(name string) (sql.NullInt64, error) {
+	if name == "" {
+		return sql.NullInt64{}, nil
+	}
+	if id, ok := im.places[strings.ToLower(name)]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+
+	place := &Place{Name: name}
+	if err := place.Save(im.tx); err != nil {
+		return sql.NullInt64{}, err
+	}
+	im.places[strings.ToLower(name)] = place.Id
+	im.result.Places = append(im.result.Places, name)
+	return sql.NullInt64{Int64: place.Id, Valid: true}, nil
+}
+
+// match looks up the existing part of an imported part, first by the order
+// numbers of known distributors, then by name, category and value. It
+// returns nil if there is none.
+func (im *inventoryImporter) match(p *importer.Part, categoryId int64) (*Part, error) {
+	part := new(Part)
+	for _, d := range p.Distributors {
+		distributorId, ok := im.distributors[strings.ToLower(d.Name)]
+		if !ok || d.Key == "" {
+			continue
+		}
+		err := im.tx.Get(part, `SELECT * FROM 'part' WHERE "id" IN
+		(SELECT "part_id" FROM 'distributor_part' WHERE "distributor_id" = ?
+		AND "key" = ?) LIMIT 1`, distributorId, d.Key)
+		if err != sql.ErrNoRows {
+			return part, err
+		}
+	}
+
+	query := `SELECT * FROM 'part' WHERE "name" = ? AND "category_id" = ?`
+	args := []interface{}{p.Name, categoryId}
+	if p.Value.Valid {
+		query += ` AND "value_decimal" IS ?`
+		args = append(args, si.New(p.Value.Float64).Decimal())
+	}
+
+	err := im.tx.Get(part, query+` ORDER BY "id" ASC LIMIT 1`, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return part, err
+}
+
+func (im *inventoryImporter) importPart(p *importer.Part) error {
+	categoryId, err := im.category(p.Category)
+	if err != nil {
+		return err
+	}
+	placeId, err := im.place(p.Place)
+	if err != nil {
+		return err
+	}
+
+	part, err := im.match(p, categoryId)
+	if err != nil {
+		return err
+	}
+
+	if part == nil {
+		part = &Part{
+			Name: p.Name,
+			Description: sql.NullString{
+				String: p.Description,
+				Valid:  p.Description != "",
+			},
+			Value:      p.Value,
+			CategoryId: categoryId,
+			PlaceId:    placeId,
+			CreatedAt:  time.Now(),
+		}
+		if err := part.Save(im.tx); err != nil {
+			return err
+		}
+		im.result.Parts++
+	} else {
+		// Only the given fields of the imported part replace the existing
+		// ones
+		updated := *part
+		if p.Description != "" {
+			updated.Description = sql.NullString{String: p.Description, Valid: true}
+		}
+		if p.Value.Valid {
+			updated.Value = p.Value
+		}
+		if placeId.Valid {
+			updated.PlaceId = placeId
+		}
+		if updated != *part {
+			if err := updated.Save(im.tx); err != nil {
+				return err
+			}
+		}
+		part = &updated
+		im.result.Matched = append(im.result.Matched, part.Name)
+	}
+
+	latest, err := part.LatestAmount(im.tx)
+	if err != nil {
+		return err
+	}
+	if latest == nil || latest.Amount != p.Stock {
+		partAmount := &PartAmount{
+			PartId:    part.Id,
+			Amount:    p.Stock,
+			Timestamp: time.Now(),
+		}
+		if err := partAmount.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range p.Distributors {
+		distributorId, ok := im.distributors[strings.ToLower(d.Name)]
+		if !ok {
+			im.problem("part "+p.Name, "unknown distributor "+d.Name+", order number "+d.Key)
+			continue
+		}
+
+		var count int64
+		err := im.tx.Get(&count, `SELECT COUNT(*) FROM 'distributor_part'
+		WHERE "distributor_id" = ? AND "part_id" = ? AND "key" = ?`,
+			distributorId, part.Id, d.Key)
+		if err != nil {
+			return err
+		} else if count != 0 {
+			continue
+		}
+
+		distributorPart := &DistributorPart{
+			DistributorId: distributorId,
+			PartId:        part.Id,
+			Price:         d.Price,
+			Key:           d.Key,
+		}
+		if err := distributorPart.Save(im.tx); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range p.Attachments {
+		if err := im.importAttachment(part, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// importAttachment stores an attachment file, missing files are reported.
+// Attachments with the name of an existing attachment of the part are
+// skipped.
+func (im *inventoryImporter) importAttachment(part *Part, a importer.Attachment) error {
+	var count int64
+	err := im.tx.Get(&count, `SELECT COUNT(*) FROM 'attachment'
+	WHERE "part_id" = ? AND "name" = ?`, part.Id, a.Name)
+	if err != nil || count != 0 {
+		return err
+	}
+
+	file, err := os.Open(filepath.Join(im.files, filepath.FromSlash(path.Clean("/"+a.Path))))
+	if err != nil {
+		im.problem("part "+part.Name, "attachment "+a.Path+" is missing")
+		return nil
+	}
+	defer file.Close()
+
+	im.result.Attachments++
+	if im.store == nil {
+		return nil
+	}
+
+	dst, key, err := im.store.Create()
+	if err != nil {
+		return err
+	}
+	im.result.Blobs = append(im.result.Blobs, key)
+	_, err = io.Copy(dst, file)
+	if err == nil {
+		err = dst.Close()
+	} else {
+		dst.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	mediaType := a.Type
+	if mediaType == "" {
+		mediaType = mime.TypeByExtension(path.Ext(a.Name))
+	}
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+
+	attachment := &Attachment{
+		Key:       key,
+		Name:      a.Name,
+		Type:      mediaType,
+		CreatedAt: time.Now(),
+		PartId:    part.Id,
+	}
+	return attachment.Save(im.tx)
+}
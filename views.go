@@ -0,0 +1,329 @@
+package inventory
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ViewPreviewSize is the number of parts shown for a saved view on the
+// dashboard
+var ViewPreviewSize = 5
+
+type savedViewSummary struct {
+	SavedView
+	Count int64
+}
+
+type savedViewPanel struct {
+	savedViewSummary
+	Parts []PartView
+}
+
+// Where returns a condition for the WHERE clause of a query on part_view and
+// its arguments, which select the parts of the saved view
+func (v *SavedView) Where() (string, []interface{}, error) {
+	switch v.Kind {
+	case SavedViewParts:
+		form, err := url.ParseQuery(v.Query)
+		if err != nil {
+			return "", nil, err
+		}
+		filter, err := loadPartsFilter(form)
+		if err != nil {
+			return "", nil, err
+		}
+		where, args := filter.SQL()
+		return where, args, nil
+	case SavedViewSearch:
+		sq, err := parseSearchQuery(v.Query)
+		if err != nil {
+			return "", nil, err
+		}
+		where, args := sq.Where()
+		return where, args, nil
+	}
+	return "", nil, fmt.Errorf("invalid view kind %q", v.Kind)
+}
+
+// Count returns the number of parts currently matched by the saved view
+func (v *SavedView) Count(db sqlx.Queryer) (count int64, err error) {
+	where, args, err := v.Where()
+	if err != nil {
+		return
+	}
+	err = db.QueryRowx(`SELECT COUNT(*) FROM 'part_view' WHERE `+where,
+		args...).Scan(&count)
+	return
+}
+
+// VisibleTo reports whether the user with the given id may see the view. Pass
+// zero for anonymous users.
+func (v *SavedView) VisibleTo(userId int64) bool {
+	return v.Shared || !v.OwnerId.Valid || v.OwnerId.Int64 == userId
+}
+
+// visibleViewsQuery selects the views which are shared or owned by a user
+const visibleViewsQuery = ` FROM 'saved_view'
+WHERE "shared" OR "owner_id" IS NULL OR "owner_id" = ? ORDER BY "name" ASC`
+
+// visibleViews returns all views which are shared or owned by the given user
+func (app *Application) visibleViews(db sqlx.Queryer, userId int64) ([]SavedView, error) {
+	views := []SavedView{}
+	err := sqlx.Select(db, &views, `SELECT *`+visibleViewsQuery, userId)
+	return views, err
+}
+
+// navbarViews returns the ids and names of the visible views, which are
+// listed on every page
+func (app *Application) navbarViews(db sqlx.Queryer, userId int64) ([]SavedView, error) {
+	views := []SavedView{}
+	err := sqlx.Select(db, &views, `SELECT "id", "name"`+visibleViewsQuery, userId)
+	return views, err
+}
+
+// countViews returns the views together with their current part count
+func countViews(db sqlx.Queryer, views []SavedView) []savedViewSummary {
+	summaries := make([]savedViewSummary, len(views))
+	for n, view := range views {
+		var err error
+		summaries[n].SavedView = view
+		summaries[n].Count, err = view.Count(db)
+		if err != nil {
+			// A broken query should not break the page
+			log.Printf("saved view %d: %v", view.Id, err)
+		}
+	}
+	return summaries
+}
+
+// dashboardViews returns the visible views which should be shown as panel on
+// the dashboard, together with their part count and a preview of their parts
+func (app *Application) dashboardViews(tx *sqlx.Tx, userId int64) ([]savedViewPanel, error) {
+	views, err := app.visibleViews(tx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	panels := []savedViewPanel{}
+	for _, view := range views {
+		if !view.Dashboard {
+			continue
+		}
+
+		where, args, err := view.Where()
+		if err != nil {
+			continue
+		}
+
+		panel := savedViewPanel{savedViewSummary: countViews(tx, []SavedView{view})[0]}
+		err = tx.Select(&panel.Parts, `SELECT * FROM 'part_view' WHERE `+where+`
+		ORDER BY "id" DESC LIMIT `+strconv.Itoa(ViewPreviewSize), args...)
+		if err != nil {
+			return nil, err
+		}
+		panels = append(panels, panel)
+	}
+	return panels, nil
+}
+
+func (app *Application) ListViewsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == "POST" {
+		app.CreateViewHandler(w, r)
+		return
+	}
+
+	userId, _ := app.currentUserId(r)
+
+	views, err := app.visibleViews(app.DB, userId)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"Views":  countViews(app.DB, views),
+		"UserId": userId,
+	}, "ListViews", "Layout")
+}
+
+func (app *Application) CreateViewHandler(w http.ResponseWriter, r *http.Request) {
+	err := r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	view := &SavedView{
+		Kind:      SavedViewParts,
+		CreatedAt: time.Now(),
+	}
+	err = view.LoadForm(r.PostForm)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	if view.Name == "" {
+		http.Redirect(w, r, view.TargetURL(), http.StatusSeeOther)
+		return
+	}
+
+	// Views without an owner are visible to everyone anyway
+	if userId, ok := app.currentUserId(r); ok {
+		view.OwnerId.Int64, view.OwnerId.Valid = userId, true
+	} else {
+		view.Shared = true
+	}
+
+	_, _, err = view.Where()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	err = view.Save(app.DB)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	http.Redirect(w, r, view.URL(), http.StatusSeeOther)
+}
+
+func (app *Application) ShowViewHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	userId, _ := app.currentUserId(r)
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	view := new(SavedView)
+	err = tx.Get(view, `SELECT * FROM 'saved_view' WHERE "id" = ?`, id)
+	if app.SQLError(w, r, err) {
+		return
+	}
+
+	if !view.VisibleTo(userId) {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	where, args, err := view.Where()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	parts := []PartView{}
+	err = tx.Select(&parts, `SELECT * FROM 'part_view' WHERE `+where+`
+	ORDER BY "id" DESC`, args...)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx.Commit()
+
+	app.renderTemplate(w, r, map[string]interface{}{
+		"View":   view,
+		"Parts":  parts,
+		"UserId": userId,
+	}, "ShowView", "Layout")
+}
+
+func (app *Application) UpdateViewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	err = r.ParseForm()
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	userId, _ := app.currentUserId(r)
+
+	tx := app.DB.MustBegin()
+	defer tx.Rollback()
+
+	view := new(SavedView)
+	err = tx.Get(view, `SELECT * FROM 'saved_view' WHERE "id" = ?`, id)
+	if app.SQLError(w, r, err) {
+		return
+	}
+
+	if view.OwnerId.Valid && view.OwnerId.Int64 != userId {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	// Unchecked checkboxes are not submitted
+	view.Shared = r.PostForm.Get("shared") != "" || !view.OwnerId.Valid
+	view.Dashboard = r.PostForm.Get("dashboard") != ""
+	if name := r.PostForm.Get("name"); name != "" {
+		view.Name = name
+	}
+
+	err = view.Save(tx)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	tx.Commit()
+
+	http.Redirect(w, r, view.URL(), http.StatusSeeOther)
+}
+
+func (app *Application) DeleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	id, err := strconv.Atoi(path.Base(r.URL.Path))
+	if err != nil {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	userId, _ := app.currentUserId(r)
+
+	res, err := app.DB.Exec(`DELETE FROM 'saved_view' WHERE "id" = ?
+	AND ("owner_id" IS NULL OR "owner_id" = ?)`, id, userId)
+	if err != nil {
+		app.Error(w, err)
+		return
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		app.Error(w, err)
+		return
+	} else if aff == 0 {
+		app.NotFoundHandler(w, r)
+		return
+	}
+
+	http.Redirect(w, r, "/views", http.StatusSeeOther)
+}
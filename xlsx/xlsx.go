@@ -0,0 +1,164 @@
+// Package xlsx writes spreadsheets in the Office Open XML format. Rows are
+// streamed into the archive, so large sheets are never held in memory.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+)
+
+// ErrClosed is returned when rows are written after Close
+var ErrClosed = errors.New("xlsx: writer is closed")
+
+// staticFiles are the parts of the package which do not depend on the rows
+var staticFiles = []struct {
+	Name    string
+	Content string
+}{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+		`</Relationships>`},
+	{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+		`<fonts count="2"><font/><font><b/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border/></borders>` +
+		`<cellStyleXfs count="1"><xf/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf/><xf fontId="1" applyFont="1"/></cellXfs>` +
+		`</styleSheet>`},
+}
+
+// A Writer writes a workbook with a single sheet. The first row is written in
+// bold, as it usually contains the column headers.
+type Writer struct {
+	// SheetName is the name of the sheet, it has to be set before the first
+	// row is written
+	SheetName string
+
+	zip    *zip.Writer
+	sheet  io.Writer
+	rows   int
+	closed bool
+}
+
+// NewWriter returns a Writer which writes the workbook to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		SheetName: "Sheet1",
+		zip:       zip.NewWriter(w),
+	}
+}
+
+func (w *Writer) begin() error {
+	for _, file := range staticFiles {
+		f, err := w.zip.Create(file.Name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, file.Content); err != nil {
+			return err
+		}
+	}
+
+	f, err := w.zip.Create("xl/workbook.xml")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(f, xml.Header+`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" `+
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`+
+		`<sheets><sheet name="`+escape(w.SheetName)+`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+	if err != nil {
+		return err
+	}
+
+	// The sheet is the last file of the archive, so the rows can be written
+	// directly into it
+	w.sheet, err = w.zip.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w.sheet, xml.Header+
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return err
+}
+
+// Write writes a row. Cells which contain a plain decimal number, that is
+// one which is formatted the same after parsing, are written as numbers, all
+// others as strings.
+func (w *Writer) Write(record []string) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.sheet == nil {
+		if err := w.begin(); err != nil {
+			return err
+		}
+	}
+
+	w.rows++
+	row := `<row r="` + strconv.Itoa(w.rows) + `">`
+	style := ""
+	if w.rows == 1 {
+		style = ` s="1"`
+	}
+	for _, cell := range record {
+		if isNumber(cell) {
+			row += `<c` + style + `><v>` + cell + `</v></c>`
+		} else {
+			row += `<c t="inlineStr"` + style + `><is><t xml:space="preserve">` +
+				escape(cell) + `</t></is></c>`
+		}
+	}
+	row += `</row>`
+
+	_, err := io.WriteString(w.sheet, row)
+	return err
+}
+
+// Close finishes the workbook, it does not close the underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.sheet == nil {
+		if err := w.begin(); err != nil {
+			return err
+		}
+	}
+	w.closed = true
+
+	_, err := io.WriteString(w.sheet, `</sheetData></worksheet>`)
+	if err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+func isNumber(s string) bool {
+	val, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return false
+	}
+	return strconv.FormatFloat(val, 'f', -1, 64) == s
+}
+
+func escape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,104 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.SheetName = "Parts & more"
+	rows := [][]string{
+		{"Name", "Stock", "Key"},
+		{"Resistor <4.7k>", "2.5", "00123"},
+		{"Capacitor", "100", "1e3"},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write(rows[0]); err != ErrClosed {
+		t.Errorf("Write after Close = %v, wants %v", err, ErrClosed)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+
+		// Every part has to be well-formed
+		d := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := d.Token()
+			if err != nil {
+				if err != io.EOF {
+					t.Errorf("%s: %v", f.Name, err)
+				}
+				break
+			}
+		}
+	}
+
+	type testCase struct {
+		File     string
+		Contains string
+	}
+
+	testCases := []testCase{
+		{"[Content_Types].xml", `PartName="/xl/worksheets/sheet1.xml"`},
+		{"xl/workbook.xml", `name="Parts &amp; more"`},
+		{"xl/worksheets/sheet1.xml", `<c t="inlineStr" s="1"><is><t xml:space="preserve">Name</t></is></c>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">Resistor &lt;4.7k&gt;</t>`},
+		{"xl/worksheets/sheet1.xml", `<c><v>2.5</v></c>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">00123</t>`},
+		{"xl/worksheets/sheet1.xml", `<t xml:space="preserve">1e3</t>`},
+		{"xl/worksheets/sheet1.xml", `<row r="3">`},
+	}
+
+	for _, testCase := range testCases {
+		t.Log(testCase)
+
+		if !strings.Contains(files[testCase.File], testCase.Contains) {
+			t.Errorf("%s does not contain %s", testCase.File, testCase.Contains)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.File) != 6 {
+		t.Errorf("empty workbook has %d files, wants 6", len(r.File))
+	}
+}